@@ -0,0 +1,235 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"raytracing/output"
+	"raytracing/render"
+)
+
+// usageError marks command line mistakes, which exit with status 2.
+type usageError struct {
+	err error
+	// reported is set when the flag package already printed the error.
+	reported bool
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+type config struct {
+	scenePath  string
+	output     string
+	format     output.Format
+	quality    int
+	width      int
+	height     int
+	depth      int
+	threads    int
+	background *render.Color
+	toneMapper render.ToneMapper
+	white      *float64
+	exposure   *float64
+	linear     *bool
+	accel      render.AcceleratorKind
+	samples    int
+	pattern    *render.SamplePattern
+	integrator render.Integrator
+	tileSize   int
+	stats      bool
+	progress   bool
+	timeout    time.Duration
+}
+
+// colorFlag parses "r,g,b" with 8-bit channels.
+type colorFlag struct {
+	color **render.Color
+}
+
+func (f colorFlag) String() string {
+	if f.color == nil || *f.color == nil {
+		return ""
+	}
+	c := *f.color
+	return fmt.Sprintf("%d,%d,%d", c.R, c.G, c.B)
+}
+
+func (f colorFlag) Set(value string) error {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected r,g,b")
+	}
+	var ch [3]uint8
+	for i, part := range parts {
+		v, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+		if err != nil {
+			return fmt.Errorf("channel %q is not an integer between 0 and 255", part)
+		}
+		ch[i] = uint8(v)
+	}
+	*f.color = &render.Color{R: ch[0], G: ch[1], B: ch[2], A: 255}
+	return nil
+}
+
+func parseFlags(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{}
+	fs := flag.NewFlagSet("raytracing", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.scenePath, "scene", "scenes/default.json", "scene description `file`")
+	fs.StringVar(&cfg.output, "o", "img.png", "output image `file`")
+	formatName := fs.String("format", "", "output `format`: png, jpeg, bmp, ppm or pfm (default from the -o extension)")
+	fs.IntVar(&cfg.quality, "quality", output.DefaultQuality, "JPEG quality from 1 to 100")
+	fs.IntVar(&cfg.width, "width", 0, "image width in pixels (default from scene)")
+	fs.IntVar(&cfg.height, "height", 0, "image height in pixels (default from scene)")
+	fs.IntVar(&cfg.depth, "depth", -1, "reflection recursion depth, negative to use the scene value")
+	fs.IntVar(&cfg.threads, "threads", runtime.NumCPU(), "number of render workers")
+	fs.Var(colorFlag{&cfg.background}, "background", "background `r,g,b` sRGB colour (default from scene)")
+	toneMap := fs.String("tonemap", "", "tone mapping `operator`: clamp, reinhard, reinhard-extended or aces (default from scene)")
+	white := fs.Float64("white", 0, "white point of the reinhard-extended operator (default from scene)")
+	exposure := fs.Float64("exposure", 0, "exposure adjustment in stops (default from scene)")
+	fs.IntVar(&cfg.samples, "samples", 0, "samples per pixel (default from scene)")
+	pattern := fs.String("pattern", "", "sample `pattern`: grid, jittered, random, halton or sobol (default from scene)")
+	fs.IntVar(&cfg.tileSize, "tile", render.DefaultTileSize, "edge length of the square tiles workers render, in pixels")
+	fs.BoolVar(&cfg.stats, "stats", false, "print render and per-tile timing statistics to stderr")
+	fs.BoolVar(&cfg.progress, "progress", false, "report progress on stderr while rendering")
+	fs.DurationVar(&cfg.timeout, "timeout", 0, "abort the render after this `duration`, zero for no limit")
+	integrator := fs.String("integrator", "", "light transport `algorithm`: whitted or path (default from scene)")
+	accel := fs.String("accel", "bvh", "ray acceleration `structure`: bvh or linear")
+	linear := fs.Bool("linear", false, "write linear values without the sRGB transfer curve (default from scene)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: raytracing [flags]\n\nRenders a JSON scene description to an image.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, &usageError{err: err, reported: true}
+	}
+	if fs.NArg() > 0 {
+		return nil, &usageError{err: fmt.Errorf("unexpected argument %q", fs.Arg(0))}
+	}
+	if *formatName != "" {
+		cfg.format, err = output.ParseFormat(*formatName)
+	} else {
+		cfg.format, err = output.FormatFromPath(cfg.output)
+	}
+	if err != nil {
+		return nil, &usageError{err: err}
+	}
+	switch *accel {
+	case "bvh":
+		cfg.accel = render.BVHAccelerator
+	case "linear":
+		cfg.accel = render.LinearAccelerator
+	default:
+		return nil, &usageError{err: fmt.Errorf("unknown -accel %q", *accel)}
+	}
+	if cfg.samples < 0 {
+		return nil, &usageError{err: fmt.Errorf("-samples must be positive")}
+	}
+	if *pattern != "" {
+		p, err := render.ParseSamplePattern(*pattern)
+		if err != nil {
+			return nil, &usageError{err: err}
+		}
+		cfg.pattern = &p
+	}
+	if *integrator != "" {
+		if cfg.integrator, err = render.ParseIntegrator(*integrator); err != nil {
+			return nil, &usageError{err: err}
+		}
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if *toneMap != "" {
+		// apply fills in the white point, which may come from the scene.
+		if cfg.toneMapper, err = render.ParseToneMapper(*toneMap, 1); err != nil {
+			return nil, &usageError{err: err}
+		}
+	}
+	if set["white"] {
+		if !(*white > 0) {
+			return nil, &usageError{err: fmt.Errorf("-white must be positive")}
+		}
+		cfg.white = white
+	}
+	if set["exposure"] {
+		cfg.exposure = exposure
+	}
+	if set["linear"] {
+		cfg.linear = linear
+	}
+	if cfg.quality < 1 || cfg.quality > 100 {
+		return nil, &usageError{err: fmt.Errorf("-quality must be between 1 and 100")}
+	}
+	if cfg.width < 0 {
+		return nil, &usageError{err: fmt.Errorf("-width must be positive")}
+	}
+	if cfg.height < 0 {
+		return nil, &usageError{err: fmt.Errorf("-height must be positive")}
+	}
+	if cfg.depth > math.MaxInt8 {
+		return nil, &usageError{err: fmt.Errorf("-depth must be at most %d", math.MaxInt8)}
+	}
+	if cfg.threads <= 0 {
+		return nil, &usageError{err: fmt.Errorf("-threads must be positive")}
+	}
+	if cfg.timeout < 0 {
+		return nil, &usageError{err: fmt.Errorf("-timeout must not be negative")}
+	}
+	if cfg.tileSize <= 0 {
+		return nil, &usageError{err: fmt.Errorf("-tile must be positive")}
+	}
+	return cfg, nil
+}
+
+// apply overrides scene settings with the ones given on the command line.
+func (cfg *config) apply(scene *render.Scene, options *render.Options) {
+	if cfg.width > 0 {
+		options.Width = cfg.width
+	}
+	if cfg.height > 0 {
+		options.Height = cfg.height
+	}
+	if cfg.depth >= 0 {
+		options.RecursionDepth = cfg.depth
+	}
+	options.Threads = cfg.threads
+	options.Accelerator = cfg.accel
+	options.TileSize = cfg.tileSize
+	if cfg.samples > 0 {
+		options.Samples = cfg.samples
+	}
+	if cfg.pattern != nil {
+		options.Pattern = *cfg.pattern
+	}
+	if cfg.integrator != nil {
+		options.Integrator = cfg.integrator
+	}
+	if cfg.background != nil {
+		scene.Background = render.FromSRGB(*cfg.background)
+	}
+	if cfg.toneMapper != nil {
+		options.ToneMapping.Operator = cfg.toneMapper
+	}
+	if cfg.white != nil {
+		options.ToneMapping.White = *cfg.white
+	}
+	if op, ok := options.ToneMapping.Operator.(render.ExtendedReinhard); ok && options.ToneMapping.White > 0 {
+		op.White = options.ToneMapping.White
+		options.ToneMapping.Operator = op
+	}
+	if cfg.exposure != nil {
+		options.ToneMapping.Exposure = *cfg.exposure
+	}
+	if cfg.linear != nil {
+		options.ToneMapping.Linear = *cfg.linear
+	}
+}
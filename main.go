@@ -2,215 +2,128 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"image"
-	"image/color"
-	"image/jpeg"
-	"math"
+	"io"
 	"os"
-	"runtime"
-	"sync"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"raytracing/vector3"
+	"raytracing/output"
+	"raytracing/render"
+	"raytracing/scene"
 )
 
-type Vec3 = vector3.Vector3
-type Color = color.RGBA
-
-type LightType uint32
-
-const (
-	Point   LightType = 0
-	Ambient LightType = 1
-)
-
-var Epsilon float64 = 0.001
-var MaxIntensity float64 = 1.
-
-type Light struct {
-	lightType LightType
-	position  Vec3
-	intensity float64
-}
-
-func (light *Light) ComputeLighting(point Vec3, normal Vec3, inverseDir Vec3, specular float64, spheres []Sphere) float64 {
-	resIntensity := 0.
-	lightDir := vector3.Vector3{}
-	tMax := math.MaxFloat64
-	switch light.lightType {
-	case Ambient:
-		return light.intensity
-	case Point:
-		lightDir = vector3.Sub(light.position, point)
-		tMax = 1.
-	}
-	tMin := Epsilon
-	closestSphere, _ := FindClosest(point, lightDir, spheres, tMin, tMax)
-
-	if closestSphere.IsNull() {
-		lightValue := math.Max(0., vector3.Dot(lightDir, normal))
-		resIntensity += light.intensity * lightValue / (point.Length() * normal.Length())
-		if specular > -1 {
-			reflectDir := ReflectRay(lightDir, normal)
-			specularValue := reflectDir.Dot(inverseDir)
-			reflectDirLenght := reflectDir.Length()
-			inverseDirLenght := inverseDir.Length()
-			if reflectDirLenght == 0.0 || inverseDirLenght == 0.0 {
-				panic("ComputeLighting: Division by zero")
-			}
-			resIntensity += light.intensity * math.Pow((math.Max(0., specularValue)/(reflectDir.Length()*inverseDir.Length())), specular)
-		}
+func run(args []string) error {
+	cfg, err := parseFlags(args, os.Stderr)
+	if err != nil {
+		return err
+	}
+	sc, options, err := scene.Load(cfg.scenePath)
+	if err != nil {
+		return err
+	}
+	cfg.apply(sc, &options)
+
+	// Interrupting the command stops the render instead of killing it, so
+	// the workers wind down and the error says why.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
 	}
-	return math.Max(0., resIntensity)
-}
-
-type Sphere struct {
-	radius     float64
-	center     Vec3
-	color      Color
-	specular   float64
-	reflective float64
-}
 
-func (s *Sphere) IsNull() bool {
-	return (math.Abs(s.radius-0.) <= Epsilon) && (math.Abs(s.center.Length()-0.) <= Epsilon)
-}
+	var renderer render.Renderer
+	var stats render.RenderStats
+	if cfg.stats {
+		options.Stats = &stats
+	}
+	if cfg.progress {
+		options.Progress = progressPrinter(os.Stderr)
+	}
+	// A render with failed pixels still produces an image worth saving;
+	// the failures are reported once it is written.
+	hdr, renderErr := renderer.RenderHDR(ctx, sc, options)
+	if cfg.progress {
+		fmt.Fprintln(os.Stderr)
+	}
+	if hdr == nil {
+		return renderErr
+	}
+	if cfg.stats {
+		printStats(os.Stderr, &stats, options.Threads)
+	}
 
-func (s *Sphere) ComputeIntersection(startPoint Vec3, direction Vec3) (float64, float64) {
-	oc := startPoint.Sub(s.center)
-	a := vector3.Dot(direction, direction)
-	if a == 0.0 {
-		panic("ComputeIntersection: Division by zero")
-	}
-	b := 2 * vector3.Dot(oc, direction)
-	c := vector3.Dot(oc, oc) - s.radius*s.radius
+	// PFM keeps the linear floating-point values, everything else is 8-bit.
+	var img image.Image = hdr
+	if cfg.format != output.PFM {
+		img = hdr.ToneMap(options.ToneMapping)
+	}
 
-	//intersection equantion of a^2 + 2b + c
-	discriminant := b*b - 4*a*c
-	if discriminant < 0 {
-		return -1., -1.
-	}
-	t1 := (-b + math.Sqrt(discriminant)) / (2 * a)
-	t2 := (-b - math.Sqrt(discriminant)) / (2 * a)
-	return t1, t2
+	if err := output.Save(cfg.output, img, cfg.format, output.Options{Quality: cfg.quality}); err != nil {
+		return err
+	}
+	return renderErr
 }
 
-func FindClosest(startPoint Vec3, direction Vec3, spheres []Sphere, tMin float64, tMax float64) (Sphere, float64) {
-	closestT := math.MaxFloat64
-	closestSphere := Sphere{radius: 0, center: Vec3{X: 0, Y: 0, Z: 0}, color: Color{R: 0, G: 0, B: 0, A: 255}}
-
-	for _, sphere := range spheres {
-		t1, t2 := sphere.ComputeIntersection(startPoint, direction)
-		if t1 >= tMin && t1 <= tMax && t1 < closestT {
-			closestSphere = sphere
-			closestT = t1
-		}
-		if t2 >= tMin && t2 <= tMax && t2 < closestT {
-			closestSphere = sphere
-			closestT = t2
+// progressPrinter returns a progress callback that keeps a status line up
+// to date on w, rewriting it at most a few times a second.
+func progressPrinter(w io.Writer) func(render.Progress) {
+	var last time.Time
+	return func(p render.Progress) {
+		if p.TilesDone < p.TilesTotal && time.Since(last) < 200*time.Millisecond {
+			return
 		}
+		last = time.Now()
+		fmt.Fprintf(w, "\r%5.1f%%  %d/%d tiles  %.2f Msamples/s  ETA %v   ",
+			100*p.Fraction(), p.TilesDone, p.TilesTotal, p.RaysPerSecond/1e6, p.ETA.Round(time.Second))
 	}
-	return closestSphere, closestT
-}
-
-func ReflectRay(ray Vec3, normal Vec3) Vec3 {
-	//in physics reflect = l - 2*n*dot(n,l)
-	//due to negate ligth vector
-	reflect := normal.Reflect(ray.Negate())
-	return reflect
 }
 
-func TraceRay(startPoint Vec3, direction Vec3, spheres []Sphere, lights []Light, recursionDepth int8, tMin float64, tMax float64) Color {
-	if direction.Length() == 0.0 {
-		fmt.Println("Warning: ray direction is zero")
+// printStats summarizes how the work was spread over the workers.
+func printStats(w io.Writer, stats *render.RenderStats, threads int) {
+	fmt.Fprintf(w, "rendered %d tiles in %v\n", len(stats.Tiles), stats.Elapsed.Round(time.Millisecond))
+	if len(stats.Tiles) == 0 {
+		return
 	}
-
-	closestSphere, closestT := FindClosest(startPoint, direction, spheres, tMin, tMax)
-	if closestSphere.IsNull() {
-		return color.RGBA{R: 125, G: 125, B: 125, A: 255}
-	}
-	// P = O + tD
-	pointIntersect := vector3.Add(startPoint, direction.MulScalar(closestT))
-	// N = P - C
-	normal := vector3.Sub(pointIntersect, closestSphere.center)
-	normal = normal.Normalize()
-	lightVal := 0.
-	for _, light := range lights {
-		lightVal += light.ComputeLighting(pointIntersect, normal, direction.Negate(), closestSphere.specular, spheres)
-	}
-	lightVal = math.Min(MaxIntensity, lightVal)
-	closestSphere.color.R = uint8(float64(closestSphere.color.R) * lightVal)
-	closestSphere.color.G = uint8(float64(closestSphere.color.G) * lightVal)
-	closestSphere.color.B = uint8(float64(closestSphere.color.B) * lightVal)
-
-	localColor := closestSphere.color
-	if closestSphere.reflective <= 0 || recursionDepth <= 0 {
-		return localColor
+	busy := stats.Busy()
+	perWorker := make([]time.Duration, threads)
+	for _, tile := range stats.Tiles {
+		perWorker[tile.Worker] += tile.Duration
+	}
+	slowest, _ := stats.Slowest()
+	fmt.Fprintf(w, "mean tile %v, slowest tile %v at %v\n",
+		(busy / time.Duration(len(stats.Tiles))).Round(time.Microsecond),
+		slowest.Duration.Round(time.Microsecond), slowest.Bounds)
+	if stats.Elapsed > 0 {
+		fmt.Fprintf(w, "worker utilisation %.0f%%\n", 100*busy.Seconds()/(stats.Elapsed.Seconds()*float64(threads)))
+	}
+	for i, d := range perWorker {
+		fmt.Fprintf(w, "  worker %d: %v\n", i, d.Round(time.Millisecond))
 	}
-
-	reflectedRay := ReflectRay(direction.Negate(), normal)
-	tMin = Epsilon //Necessary offset for avoid intersection with itself
-	reflectedColor := TraceRay(pointIntersect, reflectedRay, spheres, lights, recursionDepth-1, tMin, tMax)
-
-	localColor.R = uint8(float64(localColor.R) * (1 - closestSphere.reflective))
-	localColor.G = uint8(float64(localColor.G) * (1 - closestSphere.reflective))
-	localColor.B = uint8(float64(localColor.B) * (1 - closestSphere.reflective))
-
-	reflectedColor.R = uint8(float64(reflectedColor.R)*closestSphere.reflective) + localColor.R
-	reflectedColor.G = uint8(float64(reflectedColor.G)*closestSphere.reflective) + localColor.G
-	reflectedColor.B = uint8(float64(reflectedColor.B)*closestSphere.reflective) + localColor.B
-
-	return reflectedColor
 }
 
 func main() {
-
-	spheres := [4]Sphere{{radius: 1, center: Vec3{X: 0, Y: -1, Z: 3}, color: Color{R: 255, G: 0, B: 0, A: 255}, specular: 100, reflective: 0.01},
-		{radius: 1, center: Vec3{X: -2, Y: 0, Z: 3}, color: Color{R: 0, G: 255, B: 0, A: 255}, specular: 25, reflective: 0.5},
-		{radius: 1, center: Vec3{X: 2, Y: 0, Z: 3}, color: Color{R: 0, G: 0, B: 255, A: 255}, specular: 15, reflective: 0.1},
-		{radius: 2000, center: Vec3{X: 0, Y: -2001, Z: 5}, color: Color{R: 255, G: 255, B: 0, A: 255}, specular: 1000, reflective: 0.}}
-
-	lights := [3]Light{{lightType: Point, position: Vec3{X: -4, Y: 5, Z: 2}, intensity: 0.2},
-		{lightType: Point, position: Vec3{X: 2, Y: 1, Z: 0}, intensity: 0.2},
-		{lightType: Ambient, intensity: 0.3}}
-	rayDistance := 1.
-
-	start := Vec3{X: 0, Y: 0, Z: 0}
-	w, h := 2048, 2048
-	img := image.NewRGBA(image.Rect(0, 0, w, h))
-	if img == nil {
-		panic("Can't create RGBA Image")
+	err := run(os.Args[1:])
+	if err == nil {
+		return
 	}
-
-	cpus := runtime.NumCPU()
-	var wg sync.WaitGroup
-
-	for i := 0; i < cpus; i++ {
-		wg.Add(1)
-		go func(i int) {
-			defer wg.Done()
-			for row := i; row < h; row += cpus {
-				for col := 0; col < w; col++ {
-					// Normalized pixed coordinates to [-1, 1]
-					x := ((float64(row)+0.5)*2/float64(w) - 1)
-					y := 1 - ((float64(col) + 0.5) * 2 / float64(h))
-					rayDirection := Vec3{X: float64(x), Y: float64(y), Z: rayDistance}
-					tMin := 1.
-					tMax := math.MaxFloat64
-					recursionDepth := 3
-					clr := TraceRay(start, rayDirection, spheres[:], lights[:], int8(recursionDepth), tMin, tMax)
-					img.Set(row, col, clr)
-				}
-			}
-		}(i)
-	}
-	wg.Wait()
-	f, err := os.Create("img.png")
-	if err != nil {
-		panic(err)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
 	}
-	defer f.Close()
-	if err = jpeg.Encode(f, img, nil); err != nil {
-		fmt.Printf("failed to encode: %v", err)
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		if !usageErr.reported {
+			fmt.Fprintln(os.Stderr, "raytracing:", err)
+		}
+		os.Exit(2)
 	}
+	fmt.Fprintln(os.Stderr, "raytracing:", err)
+	os.Exit(1)
 }
@@ -0,0 +1,39 @@
+package obj
+
+import (
+	"raytracing/render"
+)
+
+// Triangles converts every face of the model into a triangle placed with
+// transform. Faces with degenerate geometry are skipped. Vertex normals are
+// used for smooth shading when all corners of a face have one.
+func (m *Model) Triangles(transform render.Transform, surface render.Material) []*render.Triangle {
+	var triangles []*render.Triangle
+	for _, group := range m.Groups {
+		for _, face := range group.Faces {
+			triangle := &render.Triangle{
+				V0:      transform.Point(m.Positions[face[0].V]),
+				V1:      transform.Point(m.Positions[face[1].V]),
+				V2:      transform.Point(m.Positions[face[2].V]),
+				Surface: surface,
+			}
+			if normal := triangle.FaceNormal(); normal.Length() == 0 {
+				continue
+			}
+			if face[0].N >= 0 {
+				triangle.N0 = transform.Normal(m.Normals[face[0].N])
+				triangle.N1 = transform.Normal(m.Normals[face[1].N])
+				triangle.N2 = transform.Normal(m.Normals[face[2].N])
+				triangle.HasNormals = true
+			}
+			if face[0].T >= 0 {
+				triangle.UV0 = m.UVs[face[0].T]
+				triangle.UV1 = m.UVs[face[1].T]
+				triangle.UV2 = m.UVs[face[2].T]
+				triangle.HasUVs = true
+			}
+			triangles = append(triangles, triangle)
+		}
+	}
+	return triangles
+}
@@ -0,0 +1,230 @@
+// Package obj reads Wavefront OBJ meshes.
+//
+// Only geometry is supported: vertices (v), normals (vn), texture
+// coordinates (vt), faces (f), groups (g) and objects (o). Polygons are
+// split into triangle fans. Other statements such as materials or smoothing
+// groups are ignored.
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"raytracing/render"
+)
+
+// Error reports a malformed line of an OBJ file.
+type Error struct {
+	Name string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Name, e.Line, e.Msg)
+}
+
+// Index refers to the attributes of a face corner. Indices are 0-based;
+// T and N are -1 when the corner has no texture coordinate or normal.
+type Index struct {
+	V, T, N int
+}
+
+// Face is a triangle of a model.
+type Face [3]Index
+
+// Group is a named set of faces, started by a "g" or "o" statement. Faces
+// before the first one belong to a group named "default".
+type Group struct {
+	Name  string
+	Faces []Face
+}
+
+type Model struct {
+	Positions []render.Vec3
+	Normals   []render.Vec3
+	UVs       []render.UV
+	Groups    []Group
+}
+
+// Load reads the OBJ file at path.
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(path, f)
+}
+
+// Parse reads an OBJ model from r. The name is only used in error messages.
+func Parse(name string, r io.Reader) (*Model, error) {
+	p := parser{name: name, model: &Model{}, group: -1}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		p.line++
+		if err := p.parseLine(scanner.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return p.model, nil
+}
+
+type parser struct {
+	name  string
+	line  int
+	model *Model
+	// group is the index of the current group in model.Groups, -1 before
+	// the first one.
+	group int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &Error{Name: p.name, Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseLine(line string) error {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	args := fields[1:]
+	switch fields[0] {
+	case "v":
+		// An optional fourth weight component is ignored.
+		if len(args) != 3 && len(args) != 4 {
+			return p.errorf("vertex needs 3 coordinates, got %d", len(args))
+		}
+		v, err := p.vector(args[:3])
+		if err != nil {
+			return err
+		}
+		p.model.Positions = append(p.model.Positions, v)
+	case "vn":
+		if len(args) != 3 {
+			return p.errorf("normal needs 3 coordinates, got %d", len(args))
+		}
+		n, err := p.vector(args)
+		if err != nil {
+			return err
+		}
+		p.model.Normals = append(p.model.Normals, n)
+	case "vt":
+		if len(args) < 1 || len(args) > 3 {
+			return p.errorf("texture coordinate needs 1 to 3 components, got %d", len(args))
+		}
+		var uv [2]float64
+		for i := 0; i < len(args) && i < 2; i++ {
+			var err error
+			if uv[i], err = p.float(args[i]); err != nil {
+				return err
+			}
+		}
+		p.model.UVs = append(p.model.UVs, render.UV{U: uv[0], V: uv[1]})
+	case "f":
+		return p.face(args)
+	case "g", "o":
+		name := strings.Join(args, " ")
+		if name == "" {
+			name = "default"
+		}
+		p.model.Groups = append(p.model.Groups, Group{Name: name})
+		p.group = len(p.model.Groups) - 1
+	}
+	return nil
+}
+
+func (p *parser) float(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+func (p *parser) vector(args []string) (render.Vec3, error) {
+	var c [3]float64
+	for i := range c {
+		var err error
+		if c[i], err = p.float(args[i]); err != nil {
+			return render.Vec3{}, err
+		}
+	}
+	return render.Vec3{X: c[0], Y: c[1], Z: c[2]}, nil
+}
+
+func (p *parser) face(args []string) error {
+	if len(args) < 3 {
+		return p.errorf("face needs at least 3 vertices, got %d", len(args))
+	}
+	corners := make([]Index, len(args))
+	for i, arg := range args {
+		var err error
+		if corners[i], err = p.corner(arg); err != nil {
+			return err
+		}
+		if (corners[i].T < 0) != (corners[0].T < 0) || (corners[i].N < 0) != (corners[0].N < 0) {
+			return p.errorf("face mixes vertices with and without texture coordinates or normals")
+		}
+	}
+	if p.group < 0 {
+		p.model.Groups = append(p.model.Groups, Group{Name: "default"})
+		p.group = len(p.model.Groups) - 1
+	}
+	group := &p.model.Groups[p.group]
+	for i := 1; i+1 < len(corners); i++ {
+		group.Faces = append(group.Faces, Face{corners[0], corners[i], corners[i+1]})
+	}
+	return nil
+}
+
+// corner parses "v", "v/t", "v//n" or "v/t/n".
+func (p *parser) corner(s string) (Index, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return Index{}, p.errorf("invalid face vertex %q", s)
+	}
+	index := Index{T: -1, N: -1}
+	var err error
+	if index.V, err = p.index(parts[0], len(p.model.Positions), "vertex"); err != nil {
+		return Index{}, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if index.T, err = p.index(parts[1], len(p.model.UVs), "texture coordinate"); err != nil {
+			return Index{}, err
+		}
+	}
+	if len(parts) > 2 {
+		if index.N, err = p.index(parts[2], len(p.model.Normals), "normal"); err != nil {
+			return Index{}, err
+		}
+	}
+	return index, nil
+}
+
+// index resolves a 1-based or negative (relative to the end) OBJ index
+// into a 0-based one.
+func (p *parser) index(s string, count int, kind string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, p.errorf("invalid %s index %q", kind, s)
+	}
+	switch {
+	case i > 0 && i <= count:
+		return i - 1, nil
+	case i < 0 && -i <= count:
+		return count + i, nil
+	}
+	return 0, p.errorf("%s index %d out of range, %d defined so far", kind, i, count)
+}
@@ -0,0 +1,142 @@
+package obj
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const square = `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+`
+
+func TestParseFaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		groups []Group
+	}{
+		{
+			name: "positions only",
+			data: square + "f 1 2 3\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, -1, -1}, {1, -1, -1}, {2, -1, -1}},
+			}}},
+		},
+		{
+			name: "relative indices",
+			data: square + "f -4 -3 -2\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, -1, -1}, {1, -1, -1}, {2, -1, -1}},
+			}}},
+		},
+		{
+			name: "relative indices count from the current line",
+			data: "v 0 0 0\nv 1 0 0\nv 1 1 0\nf -3 -2 -1\nv 0 1 0\nf -4 -2 -1\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, -1, -1}, {1, -1, -1}, {2, -1, -1}},
+				{{0, -1, -1}, {2, -1, -1}, {3, -1, -1}},
+			}}},
+		},
+		{
+			name: "quad fan",
+			data: square + "f 1 2 3 4\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, -1, -1}, {1, -1, -1}, {2, -1, -1}},
+				{{0, -1, -1}, {2, -1, -1}, {3, -1, -1}},
+			}}},
+		},
+		{
+			name: "texture coordinates",
+			data: square + "f 1/1 2/2 3/3\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, 0, -1}, {1, 1, -1}, {2, 2, -1}},
+			}}},
+		},
+		{
+			name: "normals without texture coordinates",
+			data: square + "f 1//1 2//1 3//1\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, -1, 0}, {1, -1, 0}, {2, -1, 0}},
+			}}},
+		},
+		{
+			name: "all attributes",
+			data: square + "f 1/1/1 2/2/1 3/3/-1\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, 0, 0}, {1, 1, 0}, {2, 2, 0}},
+			}}},
+		},
+		{
+			name: "groups and objects",
+			data: square + "f 1 2 3\ng front side\nf 1 3 4\no cube\ng\nf 2 3 4\n",
+			groups: []Group{
+				{Name: "default", Faces: []Face{{{0, -1, -1}, {1, -1, -1}, {2, -1, -1}}}},
+				{Name: "front side", Faces: []Face{{{0, -1, -1}, {2, -1, -1}, {3, -1, -1}}}},
+				{Name: "cube"},
+				{Name: "default", Faces: []Face{{{1, -1, -1}, {2, -1, -1}, {3, -1, -1}}}},
+			},
+		},
+		{
+			name: "comments and unsupported statements",
+			data: "# a triangle\nmtllib x.mtl\n" + square + "usemtl red\ns 1\nf 1 2 3 # the first half\n",
+			groups: []Group{{Name: "default", Faces: []Face{
+				{{0, -1, -1}, {1, -1, -1}, {2, -1, -1}},
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := Parse("test.obj", strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(model.Groups, tt.groups) {
+				t.Errorf("Parse() groups = %v, want %v", model.Groups, tt.groups)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+		msg  string
+	}{
+		{"index zero", square + "f 0 1 2\n", 10, "vertex index 0 out of range"},
+		{"index past the end", square + "f 1 2 5\n", 10, "vertex index 5 out of range"},
+		{"relative index past the start", square + "f 1 2 -5\n", 10, "vertex index -5 out of range"},
+		{"vertex defined later", "v 0 0 0\nv 1 0 0\nf 1 2 3\nv 1 1 0\n", 3, "vertex index 3 out of range"},
+		{"texture coordinate out of range", square + "f 1/5 2/1 3/1\n", 10, "texture coordinate index 5 out of range"},
+		{"normal out of range", square + "f 1//2 2//1 3//1\n", 10, "normal index 2 out of range"},
+		{"normals on some corners only", square + "f 1//1 2 3//1\n", 10, "face mixes"},
+		{"texture coordinates on some corners only", square + "f 1/1 2/2 3\n", 10, "face mixes"},
+		{"invalid index", square + "f 1 2 x\n", 10, `invalid vertex index "x"`},
+		{"too many slashes", square + "f 1/1/1/1 2 3\n", 10, "invalid face vertex"},
+		{"too few vertices", square + "f 1 2\n", 10, "face needs at least 3 vertices"},
+		{"short vertex", "v 1 2\n", 1, "vertex needs 3 coordinates"},
+		{"invalid number", "v 0 0 0\n\nvn 0 one 0\n", 3, `invalid number "one"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("test.obj", strings.NewReader(tt.data))
+			var objErr *Error
+			if !errors.As(err, &objErr) {
+				t.Fatalf("Parse() error = %v, want *Error", err)
+			}
+			if objErr.Name != "test.obj" || objErr.Line != tt.line || !strings.Contains(objErr.Msg, tt.msg) {
+				t.Errorf("Parse() error = %q, want line %d containing %q", err, tt.line, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+)
+
+const (
+	bmpFileHeaderSize = 14
+	bmpInfoHeaderSize = 40
+)
+
+// encodeBMP writes an uncompressed 24-bit bottom-up Windows bitmap.
+func encodeBMP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	rowSize := (3*width + 3) &^ 3
+	imageSize := rowSize * height
+	offset := bmpFileHeaderSize + bmpInfoHeaderSize
+
+	header := make([]byte, offset)
+	header[0], header[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(header[2:], uint32(offset+imageSize))
+	binary.LittleEndian.PutUint32(header[10:], uint32(offset))
+	info := header[bmpFileHeaderSize:]
+	binary.LittleEndian.PutUint32(info[0:], bmpInfoHeaderSize)
+	binary.LittleEndian.PutUint32(info[4:], uint32(width))
+	binary.LittleEndian.PutUint32(info[8:], uint32(height))
+	binary.LittleEndian.PutUint16(info[12:], 1)  // planes
+	binary.LittleEndian.PutUint16(info[14:], 24) // bits per pixel
+	binary.LittleEndian.PutUint32(info[20:], uint32(imageSize))
+	// 2835 pixels per metre is 72 DPI.
+	binary.LittleEndian.PutUint32(info[24:], 2835)
+	binary.LittleEndian.PutUint32(info[28:], 2835)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	row := make([]byte, rowSize)
+	for y := b.Max.Y - 1; y >= b.Min.Y; y-- {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			i := 3 * (x - b.Min.X)
+			row[i], row[i+1], row[i+2] = uint8(bl>>8), uint8(g>>8), uint8(r>>8)
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
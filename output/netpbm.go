@@ -0,0 +1,67 @@
+package output
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// encodePPM writes a binary (P6) portable pixmap with 8-bit channels.
+func encodePPM(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	if _, err := fmt.Fprintf(w, "P6\n%d %d\n255\n", b.Dx(), b.Dy()); err != nil {
+		return err
+	}
+	row := make([]byte, 3*b.Dx())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			i := 3 * (x - b.Min.X)
+			row[i], row[i+1], row[i+2] = uint8(r>>8), uint8(g>>8), uint8(bl>>8)
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FloatImage is an image with floating-point channels. The PFM encoder
+// stores them unclamped; other images are written with values in [0, 1].
+type FloatImage interface {
+	image.Image
+	FloatAt(x, y int) (r, g, b float32)
+}
+
+// encodePFM writes a little-endian colour portable float map. Rows are
+// stored bottom to top as the format requires.
+func encodePFM(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	floatImg, _ := img.(FloatImage)
+	// A negative scale marks little-endian data.
+	if _, err := fmt.Fprintf(w, "PF\n%d %d\n-1.0\n", b.Dx(), b.Dy()); err != nil {
+		return err
+	}
+	row := make([]byte, 12*b.Dx())
+	for y := b.Max.Y - 1; y >= b.Min.Y; y-- {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var r, g, bl float32
+			if floatImg != nil {
+				r, g, bl = floatImg.FloatAt(x, y)
+			} else {
+				r16, g16, b16, _ := img.At(x, y).RGBA()
+				r, g, bl = float32(r16)/0xffff, float32(g16)/0xffff, float32(b16)/0xffff
+			}
+			i := 12 * (x - b.Min.X)
+			binary.LittleEndian.PutUint32(row[i:], math.Float32bits(r))
+			binary.LittleEndian.PutUint32(row[i+4:], math.Float32bits(g))
+			binary.LittleEndian.PutUint32(row[i+8:], math.Float32bits(bl))
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
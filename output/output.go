@@ -0,0 +1,118 @@
+// Package output encodes rendered images to files.
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Format int
+
+const (
+	PNG Format = iota
+	JPEG
+	BMP
+	PPM
+	PFM
+)
+
+var formatNames = map[Format]string{
+	PNG:  "png",
+	JPEG: "jpeg",
+	BMP:  "bmp",
+	PPM:  "ppm",
+	PFM:  "pfm",
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ParseFormat returns the format with the given name, e.g. "png" or "jpg".
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "png":
+		return PNG, nil
+	case "jpeg", "jpg":
+		return JPEG, nil
+	case "bmp":
+		return BMP, nil
+	case "ppm":
+		return PPM, nil
+	case "pfm":
+		return PFM, nil
+	}
+	return 0, fmt.Errorf("unknown image format %q", name)
+}
+
+// FormatFromPath picks the format matching the file extension of path.
+func FormatFromPath(path string) (Format, error) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return 0, fmt.Errorf("%s: no file extension to infer the image format from", path)
+	}
+	format, err := ParseFormat(ext[1:])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return format, nil
+}
+
+// DefaultQuality is the JPEG quality used when Options.Quality is zero.
+const DefaultQuality = 90
+
+type Options struct {
+	// Quality is the JPEG quality from 1 to 100. Other formats ignore it.
+	Quality int
+}
+
+func Encode(w io.Writer, img image.Image, format Format, options Options) error {
+	switch format {
+	case PNG:
+		return png.Encode(w, img)
+	case JPEG:
+		quality := options.Quality
+		if quality == 0 {
+			quality = DefaultQuality
+		}
+		if quality < 1 || quality > 100 {
+			return fmt.Errorf("jpeg quality %d out of range 1-100", quality)
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case BMP:
+		return encodeBMP(w, img)
+	case PPM:
+		return encodePPM(w, img)
+	case PFM:
+		return encodePFM(w, img)
+	}
+	return fmt.Errorf("unsupported image format %v", format)
+}
+
+// Save writes img to path, creating or truncating the file.
+func Save(path string, img image.Image, format Format, options Options) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	if err := Encode(bw, img, format, options); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,177 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gradient returns a w×h image whose pixels are all distinct so that row
+// and channel order mistakes show up.
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(10 * x), G: uint8(10 * y), B: uint8(100 + x + y), A: 255})
+		}
+	}
+	return img
+}
+
+func TestSaveFormat(t *testing.T) {
+	tests := []struct {
+		format Format
+		name   string
+	}{
+		{PNG, "png"},
+		{JPEG, "jpeg"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, gradient(5, 3), test.format, Options{}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if _, name, err := image.Decode(&buf); err != nil || name != test.name {
+				t.Errorf("Encode() decodes as %q, %v, want %q", name, err, test.name)
+			}
+
+			path := filepath.Join(t.TempDir(), "image."+test.name)
+			if err := Save(path, gradient(5, 3), test.format, Options{}); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if _, name, err := image.Decode(f); err != nil || name != test.name {
+				t.Errorf("Save() decodes as %q, %v, want %q", name, err, test.name)
+			}
+		})
+	}
+}
+
+func TestEncodeBMP(t *testing.T) {
+	const w, h = 3, 2
+	img := gradient(w, h)
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, BMP, Options{}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	data := buf.Bytes()
+
+	const rowSize = 12 // 3 pixels × 3 bytes padded to a multiple of 4
+	const offset = bmpFileHeaderSize + bmpInfoHeaderSize
+	if len(data) != offset+rowSize*h {
+		t.Fatalf("len = %d, want %d", len(data), offset+rowSize*h)
+	}
+	if string(data[:2]) != "BM" {
+		t.Errorf("magic = %q, want BM", data[:2])
+	}
+	fields := []struct {
+		name       string
+		value, off int
+	}{
+		{"file size", len(data), 2},
+		{"pixel offset", offset, 10},
+		{"width", w, 18},
+		{"height", h, 22},
+		{"image size", rowSize * h, 34},
+	}
+	for _, f := range fields {
+		if got := binary.LittleEndian.Uint32(data[f.off:]); int(got) != f.value {
+			t.Errorf("%s = %d, want %d", f.name, got, f.value)
+		}
+	}
+
+	for row := 0; row < h; row++ {
+		line := data[offset+row*rowSize : offset+(row+1)*rowSize]
+		y := h - 1 - row
+		for x := 0; x < w; x++ {
+			c := img.RGBAAt(x, y)
+			want := []byte{c.B, c.G, c.R}
+			if got := line[3*x : 3*x+3]; !bytes.Equal(got, want) {
+				t.Errorf("row %d pixel %d = %v, want %v", row, x, got, want)
+			}
+		}
+		if pad := line[3*w:]; !bytes.Equal(pad, []byte{0, 0, 0}) {
+			t.Errorf("row %d padding = %v, want zeros", row, pad)
+		}
+	}
+}
+
+// floatImage is a FloatImage whose channels are x, y and -1.
+type floatImage struct{ image.Rectangle }
+
+func (img floatImage) ColorModel() color.Model { return color.RGBAModel }
+func (img floatImage) Bounds() image.Rectangle { return img.Rectangle }
+func (img floatImage) At(x, y int) color.Color { return color.RGBA{} }
+func (img floatImage) FloatAt(x, y int) (r, g, b float32) {
+	return float32(x), float32(y), -1
+}
+
+func TestEncodePFM(t *testing.T) {
+	const w, h = 2, 3
+	var buf bytes.Buffer
+	if err := Encode(&buf, floatImage{image.Rect(0, 0, w, h)}, PFM, Options{}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	const header = "PF\n2 3\n-1.0\n"
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte(header)) {
+		t.Fatalf("header = %q, want %q", data[:min(len(data), len(header))], header)
+	}
+	data = data[len(header):]
+	if len(data) != 12*w*h {
+		t.Fatalf("len = %d, want %d", len(data), 12*w*h)
+	}
+	for row := 0; row < h; row++ {
+		y := h - 1 - row
+		for x := 0; x < w; x++ {
+			want := [3]float32{float32(x), float32(y), -1}
+			for c := range want {
+				i := 12*(row*w+x) + 4*c
+				if got := math.Float32frombits(binary.LittleEndian.Uint32(data[i:])); got != want[c] {
+					t.Errorf("row %d pixel %d channel %d = %v, want %v", row, x, c, got, want[c])
+				}
+			}
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		format  Format
+		wantErr bool
+	}{
+		{path: "out.png", format: PNG},
+		{path: "out.PNG", format: PNG},
+		{path: "dir.d/out.JPG", format: JPEG},
+		{path: "out.jpeg", format: JPEG},
+		{path: "out.Bmp", format: BMP},
+		{path: "out.ppm", format: PPM},
+		{path: "out.pfm", format: PFM},
+		{path: "out", wantErr: true},
+		{path: "dir.png/out", wantErr: true},
+		{path: "out.gif", wantErr: true},
+	}
+	for _, test := range tests {
+		format, err := FormatFromPath(test.path)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("FormatFromPath(%q) = %v, want error", test.path, format)
+			}
+			continue
+		}
+		if err != nil || format != test.format {
+			t.Errorf("FormatFromPath(%q) = %v, %v, want %v", test.path, format, err, test.format)
+		}
+	}
+}
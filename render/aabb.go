@@ -0,0 +1,89 @@
+package render
+
+import "math"
+
+// AABB is an axis-aligned bounding box.
+type AABB struct {
+	Min, Max Vec3
+}
+
+// Bounded is implemented by shapes with finite extent. Shapes without it,
+// such as planes, are tested against every ray.
+type Bounded interface {
+	Bounds() AABB
+}
+
+// EmptyAABB contains nothing; extending it with any point yields that point.
+func EmptyAABB() AABB {
+	inf := math.Inf(1)
+	return AABB{Min: Vec3{X: inf, Y: inf, Z: inf}, Max: Vec3{X: -inf, Y: -inf, Z: -inf}}
+}
+
+func (b AABB) Union(other AABB) AABB {
+	return AABB{
+		Min: Vec3{X: math.Min(b.Min.X, other.Min.X), Y: math.Min(b.Min.Y, other.Min.Y), Z: math.Min(b.Min.Z, other.Min.Z)},
+		Max: Vec3{X: math.Max(b.Max.X, other.Max.X), Y: math.Max(b.Max.Y, other.Max.Y), Z: math.Max(b.Max.Z, other.Max.Z)},
+	}
+}
+
+func (b AABB) Extend(p Vec3) AABB {
+	return b.Union(AABB{Min: p, Max: p})
+}
+
+func (b AABB) Centroid() Vec3 {
+	return Vec3{X: (b.Min.X + b.Max.X) / 2, Y: (b.Min.Y + b.Max.Y) / 2, Z: (b.Min.Z + b.Max.Z) / 2}
+}
+
+func (b AABB) SurfaceArea() float64 {
+	dx, dy, dz := b.Max.X-b.Min.X, b.Max.Y-b.Min.Y, b.Max.Z-b.Min.Z
+	if dx < 0 || dy < 0 || dz < 0 {
+		return 0
+	}
+	return 2 * (dx*dy + dy*dz + dz*dx)
+}
+
+func axis(v Vec3, a int) float64 {
+	switch a {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	}
+	return v.Z
+}
+
+// hit is the slab test. invDir holds the reciprocal ray direction; NaNs
+// from 0*Inf fail every comparison and so never narrow the interval.
+func (b *AABB) hit(startPoint Vec3, invDir Vec3, tMin float64, tMax float64) bool {
+	for a := 0; a < 3; a++ {
+		origin, inv := axis(startPoint, a), axis(invDir, a)
+		t0 := (axis(b.Min, a) - origin) * inv
+		t1 := (axis(b.Max, a) - origin) * inv
+		if inv < 0 {
+			t0, t1 = t1, t0
+		}
+		if t0 > tMin {
+			tMin = t0
+		}
+		if t1 < tMax {
+			tMax = t1
+		}
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Sphere) Bounds() AABB {
+	r := Vec3{X: s.Radius, Y: s.Radius, Z: s.Radius}
+	return AABB{Min: s.Center.Sub(r), Max: s.Center.Add(r)}
+}
+
+func (tr *Triangle) Bounds() AABB {
+	b := EmptyAABB().Extend(tr.V0).Extend(tr.V1).Extend(tr.V2)
+	// Pad so axis-aligned triangles do not get a flat box.
+	b.Min = b.Min.SubScalar(Epsilon)
+	b.Max = b.Max.AddScalar(Epsilon)
+	return b
+}
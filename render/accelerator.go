@@ -0,0 +1,42 @@
+package render
+
+// Accelerator finds ray hits among the shapes of a scene.
+type Accelerator interface {
+	// Closest returns the nearest shape hit in [tMin, tMax], or nil.
+	Closest(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (Shape, float64)
+	// Occluded reports whether any shape is hit in [tMin, tMax]. It stops
+	// at the first hit, which makes it cheaper than Closest for shadow rays.
+	Occluded(startPoint Vec3, direction Vec3, tMin float64, tMax float64) bool
+}
+
+type AcceleratorKind int
+
+const (
+	// BVHAccelerator builds a bounding volume hierarchy; it is the default.
+	BVHAccelerator AcceleratorKind = iota
+	// LinearAccelerator tests every shape against every ray.
+	LinearAccelerator
+)
+
+func NewAccelerator(kind AcceleratorKind, shapes []Shape) Accelerator {
+	if kind == LinearAccelerator {
+		return LinearScan(shapes)
+	}
+	return BuildBVH(shapes)
+}
+
+// LinearScan tests every shape in turn.
+type LinearScan []Shape
+
+func (l LinearScan) Closest(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (Shape, float64) {
+	return FindClosest(startPoint, direction, l, tMin, tMax)
+}
+
+func (l LinearScan) Occluded(startPoint Vec3, direction Vec3, tMin float64, tMax float64) bool {
+	for _, shape := range l {
+		if _, ok := shape.Intersect(startPoint, direction, tMin, tMax); ok {
+			return true
+		}
+	}
+	return false
+}
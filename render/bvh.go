@@ -0,0 +1,256 @@
+package render
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	bvhBins        = 16
+	bvhMaxLeafSize = 4
+	// bvhTraversalCost is the cost of visiting a node relative to one
+	// primitive intersection test in the surface area heuristic.
+	bvhTraversalCost = 0.5
+	bvhStackSize     = 64
+)
+
+// bvhNode is a node of the flattened tree. Nodes are stored depth first, so
+// the left child of an interior node directly follows it and offset holds
+// the index of the right child. For leaves offset is the first primitive
+// and count the number of primitives.
+type bvhNode struct {
+	bounds AABB
+	offset int32
+	count  int32
+	axis   int8
+}
+
+// BVH is a bounding volume hierarchy built with the surface area heuristic.
+// Shapes that are not Bounded are kept aside and tested linearly.
+type BVH struct {
+	nodes      []bvhNode
+	primitives []Shape
+	unbounded  []Shape
+}
+
+type bvhPrimitive struct {
+	shape    Shape
+	bounds   AABB
+	centroid Vec3
+}
+
+func BuildBVH(shapes []Shape) *BVH {
+	bvh := &BVH{}
+	var prims []bvhPrimitive
+	for _, shape := range shapes {
+		bounded, ok := shape.(Bounded)
+		if !ok {
+			bvh.unbounded = append(bvh.unbounded, shape)
+			continue
+		}
+		b := bounded.Bounds()
+		prims = append(prims, bvhPrimitive{shape: shape, bounds: b, centroid: b.Centroid()})
+	}
+	if len(prims) == 0 {
+		return bvh
+	}
+	bvh.nodes = make([]bvhNode, 0, 2*len(prims))
+	bvh.primitives = make([]Shape, 0, len(prims))
+	bvh.build(prims, 0)
+	return bvh
+}
+
+// build appends the subtree for prims and returns the index of its root.
+func (bvh *BVH) build(prims []bvhPrimitive, depth int) int {
+	bounds, centroids := EmptyAABB(), EmptyAABB()
+	for i := range prims {
+		bounds = bounds.Union(prims[i].bounds)
+		centroids = centroids.Extend(prims[i].centroid)
+	}
+	index := len(bvh.nodes)
+	bvh.nodes = append(bvh.nodes, bvhNode{bounds: bounds})
+
+	splitAxis, split, ok := bvhSplit(prims, bounds, centroids)
+	// Deeper trees would overflow the traversal stack.
+	if !ok || depth >= bvhStackSize-1 {
+		bvh.nodes[index].offset = int32(len(bvh.primitives))
+		bvh.nodes[index].count = int32(len(prims))
+		for i := range prims {
+			bvh.primitives = append(bvh.primitives, prims[i].shape)
+		}
+		return index
+	}
+
+	bvh.build(prims[:split], depth+1)
+	right := bvh.build(prims[split:], depth+1)
+	bvh.nodes[index].offset = int32(right)
+	bvh.nodes[index].axis = int8(splitAxis)
+	return index
+}
+
+// bvhSplit picks the cheapest binned SAH split and partitions prims around
+// it. It reports false when a leaf is cheaper than any split.
+func bvhSplit(prims []bvhPrimitive, bounds AABB, centroids AABB) (int, int, bool) {
+	if len(prims) <= 1 {
+		return 0, 0, false
+	}
+	leafCost := float64(len(prims))
+	bestCost, bestAxis, bestBin := math.Inf(1), -1, 0
+	area := bounds.SurfaceArea()
+
+	for a := 0; a < 3; a++ {
+		lo, hi := axis(centroids.Min, a), axis(centroids.Max, a)
+		if hi <= lo {
+			continue
+		}
+		var binBounds [bvhBins]AABB
+		var binCounts [bvhBins]int
+		for i := range binBounds {
+			binBounds[i] = EmptyAABB()
+		}
+		for i := range prims {
+			b := bvhBin(axis(prims[i].centroid, a), lo, hi)
+			binBounds[b] = binBounds[b].Union(prims[i].bounds)
+			binCounts[b]++
+		}
+
+		// Sweep from the right to know the cost of every right half.
+		var rightArea [bvhBins]float64
+		var rightCount [bvhBins]int
+		acc, count := EmptyAABB(), 0
+		for b := bvhBins - 1; b > 0; b-- {
+			acc = acc.Union(binBounds[b])
+			count += binCounts[b]
+			rightArea[b] = acc.SurfaceArea()
+			rightCount[b] = count
+		}
+		acc, count = EmptyAABB(), 0
+		for b := 0; b < bvhBins-1; b++ {
+			acc = acc.Union(binBounds[b])
+			count += binCounts[b]
+			if count == 0 || rightCount[b+1] == 0 {
+				continue
+			}
+			cost := bvhTraversalCost + (acc.SurfaceArea()*float64(count)+rightArea[b+1]*float64(rightCount[b+1]))/area
+			if cost < bestCost {
+				bestCost, bestAxis, bestBin = cost, a, b
+			}
+		}
+	}
+
+	if bestAxis < 0 {
+		// All centroids coincide; split in the middle if the leaf is too big.
+		if len(prims) <= bvhMaxLeafSize {
+			return 0, 0, false
+		}
+		return 0, len(prims) / 2, true
+	}
+	if bestCost >= leafCost && len(prims) <= bvhMaxLeafSize {
+		return 0, 0, false
+	}
+
+	lo, hi := axis(centroids.Min, bestAxis), axis(centroids.Max, bestAxis)
+	slices.SortStableFunc(prims, func(p, q bvhPrimitive) int {
+		pb := bvhBin(axis(p.centroid, bestAxis), lo, hi)
+		qb := bvhBin(axis(q.centroid, bestAxis), lo, hi)
+		return pb - qb
+	})
+	split := 0
+	for split < len(prims) && bvhBin(axis(prims[split].centroid, bestAxis), lo, hi) <= bestBin {
+		split++
+	}
+	return bestAxis, split, true
+}
+
+func bvhBin(v float64, lo float64, hi float64) int {
+	b := int(bvhBins * (v - lo) / (hi - lo))
+	return min(max(b, 0), bvhBins-1)
+}
+
+// Bounds returns the box around every bounded shape.
+func (bvh *BVH) Bounds() AABB {
+	if len(bvh.nodes) == 0 {
+		return EmptyAABB()
+	}
+	return bvh.nodes[0].bounds
+}
+
+func (bvh *BVH) Closest(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (Shape, float64) {
+	closestShape, closestT := FindClosest(startPoint, direction, bvh.unbounded, tMin, tMax)
+	if closestShape != nil {
+		tMax = closestT
+	}
+	if len(bvh.nodes) == 0 {
+		return closestShape, closestT
+	}
+
+	invDir := Vec3{X: 1 / direction.X, Y: 1 / direction.Y, Z: 1 / direction.Z}
+	negative := [3]bool{invDir.X < 0, invDir.Y < 0, invDir.Z < 0}
+	var stack [bvhStackSize]int32
+	top := 0
+	node := int32(0)
+	for {
+		n := &bvh.nodes[node]
+		if n.bounds.hit(startPoint, invDir, tMin, tMax) {
+			if n.count > 0 {
+				for _, shape := range bvh.primitives[n.offset : n.offset+n.count] {
+					if t, ok := shape.Intersect(startPoint, direction, tMin, tMax); ok {
+						closestShape, closestT, tMax = shape, t, t
+					}
+				}
+			} else {
+				// Visit the child nearer to the ray origin first.
+				near, far := node+1, n.offset
+				if negative[n.axis] {
+					near, far = far, near
+				}
+				stack[top] = far
+				top++
+				node = near
+				continue
+			}
+		}
+		if top == 0 {
+			break
+		}
+		top--
+		node = stack[top]
+	}
+	return closestShape, closestT
+}
+
+func (bvh *BVH) Occluded(startPoint Vec3, direction Vec3, tMin float64, tMax float64) bool {
+	if LinearScan(bvh.unbounded).Occluded(startPoint, direction, tMin, tMax) {
+		return true
+	}
+	if len(bvh.nodes) == 0 {
+		return false
+	}
+
+	invDir := Vec3{X: 1 / direction.X, Y: 1 / direction.Y, Z: 1 / direction.Z}
+	var stack [bvhStackSize]int32
+	top := 0
+	node := int32(0)
+	for {
+		n := &bvh.nodes[node]
+		if n.bounds.hit(startPoint, invDir, tMin, tMax) {
+			if n.count > 0 {
+				for _, shape := range bvh.primitives[n.offset : n.offset+n.count] {
+					if _, ok := shape.Intersect(startPoint, direction, tMin, tMax); ok {
+						return true
+					}
+				}
+			} else {
+				stack[top] = n.offset
+				top++
+				node++
+				continue
+			}
+		}
+		if top == 0 {
+			return false
+		}
+		top--
+		node = stack[top]
+	}
+}
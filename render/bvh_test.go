@@ -0,0 +1,107 @@
+package render_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"raytracing/obj"
+	"raytracing/render"
+)
+
+// meshScene loads the UV sphere model nine times over in a grid, a few
+// thousand triangles in all.
+func meshScene(tb testing.TB) []render.Shape {
+	tb.Helper()
+	model, err := obj.Load("../scenes/models/uvsphere.obj")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	material := &render.Phong{Color: render.White, Specular: -1}
+	var shapes []render.Shape
+	for i := 0; i < 9; i++ {
+		translate := render.Vec3{X: float64(i%3)*2.5 - 2.5, Y: float64(i/3)*2.5 - 2.5, Z: 6}
+		transform := render.NewTransform(translate, render.Vec3{Y: float64(i) * 20}, render.Vec3{X: 1, Y: 1, Z: 1})
+		for _, triangle := range model.Triangles(transform, material) {
+			shapes = append(shapes, triangle)
+		}
+	}
+	return shapes
+}
+
+// randomRays returns rays from around the camera position towards the
+// meshes. Every fourth ray runs parallel to an axis, which makes the
+// inverse direction used by the box tests infinite.
+func randomRays(n int) (origins []render.Vec3, directions []render.Vec3) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	axes := []render.Vec3{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1}}
+	for i := 0; i < n; i++ {
+		origin := render.Vec3{X: rng.Float64()*8 - 4, Y: rng.Float64()*8 - 4, Z: rng.Float64()*8 - 2}
+		direction := render.Vec3{X: rng.Float64()*2 - 1, Y: rng.Float64()*2 - 1, Z: rng.Float64()}
+		if i%4 == 0 {
+			direction = axes[rng.IntN(len(axes))]
+		}
+		origins = append(origins, origin)
+		directions = append(directions, direction)
+	}
+	return origins, directions
+}
+
+func TestBVHMatchesLinearScan(t *testing.T) {
+	shapes := meshScene(t)
+	// Planes are unbounded and handled next to the tree.
+	shapes = append(shapes, &render.Plane{
+		Point:   render.Vec3{Y: -3},
+		Normal:  render.Vec3{Y: 1},
+		Surface: &render.Phong{Color: render.White, Specular: -1},
+	})
+	bvh := render.BuildBVH(shapes)
+	linear := render.LinearScan(shapes)
+
+	origins, directions := randomRays(4000)
+	hits := 0
+	for i := range origins {
+		o, d := origins[i], directions[i]
+		wantShape, wantT := linear.Closest(o, d, render.Epsilon, render.Infinity)
+		gotShape, gotT := bvh.Closest(o, d, render.Epsilon, render.Infinity)
+		if gotShape != wantShape || gotT != wantT {
+			t.Fatalf("ray %d from %v along %v: BVH hit %v at %g, linear scan %v at %g", i, o, d, gotShape, gotT, wantShape, wantT)
+		}
+		if wantShape != nil {
+			hits++
+		}
+		for _, tMax := range []float64{1, 5, render.Infinity} {
+			want := linear.Occluded(o, d, render.Epsilon, tMax)
+			if got := bvh.Occluded(o, d, render.Epsilon, tMax); got != want {
+				t.Fatalf("ray %d from %v along %v: BVH occluded = %v up to %g, linear scan %v", i, o, d, got, tMax, want)
+			}
+		}
+	}
+	if hits < len(origins)/4 {
+		t.Fatalf("only %d of %d rays hit anything, the test is not exercising the tree", hits, len(origins))
+	}
+}
+
+func benchmarkClosest(b *testing.B, kind render.AcceleratorKind) {
+	accel := render.NewAccelerator(kind, meshScene(b))
+	origins, directions := randomRays(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j := i % len(origins)
+		accel.Closest(origins[j], directions[j], render.Epsilon, render.Infinity)
+	}
+}
+
+func benchmarkOccluded(b *testing.B, kind render.AcceleratorKind) {
+	accel := render.NewAccelerator(kind, meshScene(b))
+	origins, directions := randomRays(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j := i % len(origins)
+		accel.Occluded(origins[j], directions[j], render.Epsilon, render.Infinity)
+	}
+}
+
+func BenchmarkClosestBVH(b *testing.B)     { benchmarkClosest(b, render.BVHAccelerator) }
+func BenchmarkClosestLinear(b *testing.B)  { benchmarkClosest(b, render.LinearAccelerator) }
+func BenchmarkOccludedBVH(b *testing.B)    { benchmarkOccluded(b, render.BVHAccelerator) }
+func BenchmarkOccludedLinear(b *testing.B) { benchmarkOccluded(b, render.LinearAccelerator) }
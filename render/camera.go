@@ -0,0 +1,79 @@
+package render
+
+import (
+	"errors"
+	"math"
+
+	"raytracing/vector3"
+)
+
+// Camera is a pinhole camera at Position looking at LookAt. FOV is the
+// vertical field of view in degrees; the horizontal one follows from the
+// aspect ratio of the image.
+type Camera struct {
+	Position Vec3
+	LookAt   Vec3
+	Up       Vec3
+	FOV      float64
+}
+
+// DefaultCamera sits at the origin looking down +Z with a 90 degree field of
+// view, which matches a 2x2 viewport one unit away.
+func DefaultCamera() Camera {
+	return Camera{
+		LookAt: Vec3{X: 0, Y: 0, Z: 1},
+		Up:     Vec3{X: 0, Y: 1, Z: 0},
+		FOV:    90,
+	}
+}
+
+func (c *Camera) Validate() error {
+	if c.FOV <= 0 || c.FOV >= 180 {
+		return errors.New("camera: field of view must be between 0 and 180 degrees")
+	}
+	forward := vector3.Sub(c.LookAt, c.Position)
+	if forward.Length() == 0 {
+		return errors.New("camera: look-at point equals the camera position")
+	}
+	if cross := forward.Cross(c.Up); cross.Length() == 0 {
+		return errors.New("camera: up vector is parallel to the view direction")
+	}
+	return nil
+}
+
+// Ray returns the primary ray through the screen point (u, v), where both
+// coordinates run from -1 to 1 and v points up.
+func (c *Camera) Ray(u float64, v float64, aspect float64) (Vec3, Vec3) {
+	f := c.frame(aspect)
+	return f.ray(u, v)
+}
+
+// cameraFrame is the camera basis prepared for one image size.
+type cameraFrame struct {
+	origin  Vec3
+	forward Vec3
+	right   Vec3
+	up      Vec3
+}
+
+func (c *Camera) frame(aspect float64) cameraFrame {
+	forward := vector3.Sub(c.LookAt, c.Position)
+	forward = forward.Normalize()
+	right := c.Up.Cross(forward)
+	right = right.Normalize()
+	up := forward.Cross(right)
+
+	halfHeight := math.Tan(c.FOV * math.Pi / 360)
+	halfWidth := halfHeight * aspect
+	return cameraFrame{
+		origin:  c.Position,
+		forward: forward,
+		right:   right.MulScalar(halfWidth),
+		up:      up.MulScalar(halfHeight),
+	}
+}
+
+func (f *cameraFrame) ray(u float64, v float64) (Vec3, Vec3) {
+	direction := vector3.Add(f.forward, vector3.Add(f.right.MulScalar(u), f.up.MulScalar(v)))
+	return f.origin, direction
+}
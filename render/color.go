@@ -0,0 +1,98 @@
+package render
+
+import (
+	"image"
+	"image/color"
+)
+
+// RGB is a linear colour with unbounded float channels. 1 is the brightest
+// value an 8-bit image can hold, but lighting may go beyond it.
+type RGB struct {
+	R, G, B float64
+}
+
+var Black = RGB{}
+var White = RGB{R: 1, G: 1, B: 1}
+
+// FromSRGB decodes an 8-bit sRGB colour into linear RGB.
+func FromSRGB(c Color) RGB {
+	return RGB{
+		R: SRGBToLinear(float64(c.R) / 255),
+		G: SRGBToLinear(float64(c.G) / 255),
+		B: SRGBToLinear(float64(c.B) / 255),
+	}
+}
+
+func (c RGB) Add(other RGB) RGB {
+	return RGB{c.R + other.R, c.G + other.G, c.B + other.B}
+}
+
+func (c RGB) Mul(other RGB) RGB {
+	return RGB{c.R * other.R, c.G * other.G, c.B * other.B}
+}
+
+func (c RGB) MulScalar(scalar float64) RGB {
+	return RGB{c.R * scalar, c.G * scalar, c.B * scalar}
+}
+
+// Luminance is the Rec. 709 relative luminance.
+func (c RGB) Luminance() float64 {
+	return 0.2126*c.R + 0.7152*c.G + 0.0722*c.B
+}
+
+// Color clamps the channels to [0, 1] and quantizes them to 8 bits.
+func (c RGB) Color() Color {
+	return Color{R: quantize(c.R), G: quantize(c.G), B: quantize(c.B), A: 255}
+}
+
+func quantize(v float64) uint8 {
+	if !(v > 0) {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return uint8(v*255 + 0.5)
+}
+
+// HDRImage holds linear RGB pixels, row by row from the top-left corner.
+type HDRImage struct {
+	Width  int
+	Height int
+	Pix    []RGB
+}
+
+func NewHDRImage(width int, height int) *HDRImage {
+	return &HDRImage{Width: width, Height: height, Pix: make([]RGB, width*height)}
+}
+
+func (img *HDRImage) RGBAt(x int, y int) RGB {
+	return img.Pix[y*img.Width+x]
+}
+
+func (img *HDRImage) SetRGB(x int, y int, c RGB) {
+	img.Pix[y*img.Width+x] = c
+}
+
+// ColorModel, Bounds and At implement image.Image with clamped linear 8-bit
+// colours, so an HDRImage can be passed to any image encoder.
+func (img *HDRImage) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (img *HDRImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, img.Width, img.Height)
+}
+
+func (img *HDRImage) At(x int, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) {
+		return Color{}
+	}
+	return img.RGBAt(x, y).Color()
+}
+
+// FloatAt returns the unclamped linear channels for float image formats.
+func (img *HDRImage) FloatAt(x int, y int) (float32, float32, float32) {
+	c := img.RGBAt(x, y)
+	return float32(c.R), float32(c.G), float32(c.B)
+}
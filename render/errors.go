@@ -0,0 +1,107 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrDegenerateRay is reported for rays without a usable direction.
+	ErrDegenerateRay = errors.New("degenerate ray direction")
+	// ErrNonFinite is reported for pixels whose colour came out NaN or
+	// infinite, usually because of broken geometry.
+	ErrNonFinite = errors.New("non-finite pixel colour")
+)
+
+// maxPixelErrors bounds how many failures a RenderError keeps in detail.
+const maxPixelErrors = 16
+
+// PixelError is a failure to trace one pixel. The pixel is left black.
+type PixelError struct {
+	X, Y int
+	Err  error
+}
+
+func (e *PixelError) Error() string {
+	return fmt.Sprintf("pixel (%d, %d): %v", e.X, e.Y, e.Err)
+}
+
+func (e *PixelError) Unwrap() error { return e.Err }
+
+// RenderError aggregates the pixel failures of a render. Count is the total
+// number of failed pixels; Pixels holds the first few of them.
+type RenderError struct {
+	Count  int
+	Pixels []*PixelError
+}
+
+func (e *RenderError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "render: %d pixels failed", e.Count)
+	for _, p := range e.Pixels {
+		sb.WriteString("\n\t")
+		sb.WriteString(p.Error())
+	}
+	if e.Count > len(e.Pixels) {
+		fmt.Fprintf(&sb, "\n\t... and %d more", e.Count-len(e.Pixels))
+	}
+	return sb.String()
+}
+
+func (e *RenderError) Unwrap() []error {
+	errs := make([]error, len(e.Pixels))
+	for i, p := range e.Pixels {
+		errs[i] = p
+	}
+	return errs
+}
+
+func (e *RenderError) add(p *PixelError) {
+	e.Count++
+	if len(e.Pixels) < maxPixelErrors {
+		e.Pixels = append(e.Pixels, p)
+	}
+}
+
+func (e *RenderError) merge(other *RenderError) {
+	e.Count += other.Count
+	for _, p := range other.Pixels {
+		if len(e.Pixels) < maxPixelErrors {
+			e.Pixels = append(e.Pixels, p)
+		}
+	}
+}
+
+// ValidationError reports an invalid part of a scene, e.g. "shapes[2]".
+type ValidationError struct {
+	Object string
+	Msg    string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("render: invalid %s: %s", e.Object, e.Msg)
+}
+
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func finiteVec(v Vec3) bool {
+	return finite(v.X, v.Y, v.Z)
+}
+
+func (c RGB) IsFinite() bool {
+	return finite(c.R, c.G, c.B)
+}
+
+// validColor reports whether c is usable as a colour: finite and not negative.
+func validColor(c RGB) bool {
+	return c.IsFinite() && c.R >= 0 && c.G >= 0 && c.B >= 0
+}
@@ -0,0 +1,35 @@
+package render
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// Integrator computes the light arriving along camera rays.
+type Integrator interface {
+	// Radiance returns the colour seen along a ray. depth is the
+	// recursion depth of the render and rng the random stream of the
+	// pixel being traced.
+	Radiance(scene *Scene, startPoint Vec3, direction Vec3, depth int, rng *rand.Rand) RGB
+}
+
+// Whitted is the classic recursive ray tracer: direct light from every
+// light source, ambient light standing in for indirect illumination, and
+// perfect mirror and glass rays followed up to depth bounces.
+type Whitted struct{}
+
+func (Whitted) Radiance(scene *Scene, startPoint Vec3, direction Vec3, depth int, rng *rand.Rand) RGB {
+	return TraceRay(startPoint, direction, scene, int8(depth), Epsilon, Infinity)
+}
+
+// ParseIntegrator returns the integrator with the given name: whitted or
+// path.
+func ParseIntegrator(name string) (Integrator, error) {
+	switch name {
+	case "whitted":
+		return Whitted{}, nil
+	case "path":
+		return PathTracer{}, nil
+	}
+	return nil, fmt.Errorf("unknown integrator %q", name)
+}
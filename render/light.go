@@ -0,0 +1,224 @@
+package render
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"raytracing/vector3"
+)
+
+type LightType uint32
+
+const (
+	Point       LightType = 0
+	Ambient     LightType = 1
+	Directional LightType = 2
+	// Rectangle is an area light spanned by Edge1 and Edge2 around
+	// Position. It shines towards the side Edge1 x Edge2 points to.
+	Rectangle LightType = 3
+	// Disk is an area light of the given Radius around Position, shining
+	// along Direction.
+	Disk LightType = 4
+	// Spherical is a ball of light of the given Radius around Position.
+	Spherical LightType = 5
+	// Spot is a point light shining along Direction in a cone: fully
+	// inside InnerAngle, fading smoothly to nothing at OuterAngle.
+	Spot LightType = 6
+)
+
+// Attenuation dims point and spot lights with the distance d to them by
+// 1 / (Constant + Linear*d + Quadratic*d²). The zero value disables it,
+// which is how lights behaved before attenuation existed.
+type Attenuation struct {
+	Constant  float64
+	Linear    float64
+	Quadratic float64
+}
+
+// InverseSquare is the physically correct falloff of a point light.
+var InverseSquare = Attenuation{Quadratic: 1}
+
+// Factor returns the share of the light left at distance d.
+func (a Attenuation) Factor(d float64) float64 {
+	if a == (Attenuation{}) {
+		return 1
+	}
+	return 1 / (a.Constant + a.Linear*d + a.Quadratic*d*d)
+}
+
+// DefaultLightSamples is the number of shadow rays area lights cast per
+// shading point when Samples is zero.
+const DefaultLightSamples = 16
+
+// maxStackLightSamples is the most area light samples whose positions are
+// kept on the stack; larger counts allocate.
+const maxStackLightSamples = 64
+
+type Light struct {
+	Type     LightType
+	Position Vec3
+	// Direction is the way a directional light travels, e.g. down for a sun
+	// at noon. Its length does not matter.
+	Direction Vec3
+	// Color is the tint of the light and Intensity its power; the light
+	// contributes Color*Intensity.
+	Color     RGB
+	Intensity float64
+
+	// Area lights shine like a point light spread evenly over their
+	// surface, which makes their shadows soft. Each shading point casts
+	// Samples stratified shadow rays to them; more samples give smoother
+	// penumbrae.
+	Edge1   Vec3
+	Edge2   Vec3
+	Radius  float64
+	Samples int
+
+	// InnerAngle and OuterAngle are the half-angles of a spot light's
+	// cone, in radians.
+	InnerAngle  float64
+	OuterAngle  float64
+	Attenuation Attenuation
+}
+
+// ComputeLighting returns the light arriving at point, per colour channel.
+func (light *Light) ComputeLighting(point Vec3, normal Vec3, inverseDir Vec3, specular float64, occluders Accelerator) RGB {
+	diffuse, highlight := light.intensities(point, normal, inverseDir, specular, occluders)
+	return light.Color.MulScalar(math.Max(0., diffuse+highlight))
+}
+
+// ComputeLightingParts is ComputeLighting with the diffuse light and the
+// Phong highlight returned separately, for materials that vary them.
+func (light *Light) ComputeLightingParts(point Vec3, normal Vec3, inverseDir Vec3, specular float64, occluders Accelerator) (RGB, RGB) {
+	diffuse, highlight := light.intensities(point, normal, inverseDir, specular, occluders)
+	return light.Color.MulScalar(math.Max(0., diffuse)), light.Color.MulScalar(math.Max(0., highlight))
+}
+
+func (light *Light) intensities(point Vec3, normal Vec3, inverseDir Vec3, specular float64, occluders Accelerator) (float64, float64) {
+	switch light.Type {
+	case Ambient:
+		return light.Intensity, 0
+	case Point, Spot:
+		lightDir := vector3.Sub(light.Position, point)
+		diffuse, highlight := light.shine(lightDir, 1., point, normal, inverseDir, specular, occluders)
+		if diffuse+highlight > 0 {
+			factor := light.Attenuation.Factor(lightDir.Length())
+			if light.Type == Spot {
+				factor *= light.cone(lightDir)
+			}
+			diffuse, highlight = diffuse*factor, highlight*factor
+		}
+		return diffuse, highlight
+	case Directional:
+		// The sun is infinitely far away, so anything along the way shadows.
+		return light.shine(light.Direction.Negate(), math.MaxFloat64, point, normal, inverseDir, specular, occluders)
+	case Rectangle, Disk, Spherical:
+		return light.shineArea(point, normal, inverseDir, specular, occluders)
+	}
+	return 0, 0
+}
+
+// cone returns how much of a spot light reaches along -lightDir: 1 inside
+// the inner cone, 0 outside the outer one and a smoothstep in between.
+func (light *Light) cone(lightDir Vec3) float64 {
+	toPoint := lightDir.Negate()
+	axis := light.Direction.Normalize()
+	cosAngle := vector3.Dot(toPoint.Normalize(), axis)
+	cosInner, cosOuter := math.Cos(light.InnerAngle), math.Cos(light.OuterAngle)
+	if cosAngle >= cosInner {
+		return 1
+	}
+	if cosAngle <= cosOuter {
+		return 0
+	}
+	t := (cosAngle - cosOuter) / (cosInner - cosOuter)
+	return t * t * (3 - 2*t)
+}
+
+// shine returns the diffuse and specular intensity at point from light
+// arriving against lightDir, unless something up to tMax along it casts a
+// shadow.
+func (light *Light) shine(lightDir Vec3, tMax float64, point Vec3, normal Vec3, inverseDir Vec3, specular float64, occluders Accelerator) (float64, float64) {
+	// Surfaces facing away from the light get neither diffuse nor specular.
+	nDotL := vector3.Dot(lightDir, normal)
+	if nDotL <= 0 {
+		return 0, 0
+	}
+	tMin := Epsilon
+	if occluders.Occluded(point, lightDir, tMin, tMax) {
+		return 0, 0
+	}
+	diffuse := light.Intensity * nDotL / (lightDir.Length() * normal.Length())
+	highlight := 0.
+	if specular > -1 {
+		reflectDir := ReflectRay(lightDir, normal)
+		specularValue := reflectDir.Dot(inverseDir)
+		reflectDirLenght := reflectDir.Length()
+		inverseDirLenght := inverseDir.Length()
+		// Without a view or reflection direction there is no highlight.
+		if reflectDirLenght != 0.0 && inverseDirLenght != 0.0 {
+			highlight = light.Intensity * math.Pow((math.Max(0., specularValue)/(reflectDirLenght*inverseDirLenght)), specular)
+		}
+	}
+	return diffuse, highlight
+}
+
+// shineArea averages shine over points spread across an area light. The
+// points are jittered on a grid, with a random stream seeded by the shading
+// point so that renders stay reproducible. This runs for every shading
+// point, so it avoids allocating for the usual sample counts.
+func (light *Light) shineArea(point Vec3, normal Vec3, inverseDir Vec3, specular float64, occluders Accelerator) (float64, float64) {
+	samples := light.Samples
+	if samples <= 0 {
+		samples = DefaultLightSamples
+	}
+	var buffer [maxStackLightSamples][2]float64
+	var offsets [][2]float64
+	if samples <= len(buffer) {
+		offsets = buffer[:samples]
+	} else {
+		offsets = make([][2]float64, samples)
+	}
+	if samples == 1 {
+		offsets[0] = [2]float64{0.5, 0.5}
+	} else {
+		var pcg rand.PCG
+		pcg.Seed(math.Float64bits(point.X)^math.Float64bits(point.Y)<<1, math.Float64bits(point.Z))
+		// The same conversion as rand.Rand.Float64, without boxing the
+		// source into a heap-allocated rand.Rand.
+		stratify(offsets, func() float64 { return float64(pcg.Uint64()<<11>>11) / (1 << 53) })
+	}
+
+	// The sphere looks like a disk facing the shading point.
+	center, axis := light.Position, light.Direction
+	if light.Type == Spherical {
+		axis = vector3.Sub(point, center)
+	}
+	unitAxis := axis.Normalize()
+	tangent, bitangent := orthonormalBasis(unitAxis)
+
+	// Rectangles only shine on one side.
+	front := vector3.Cross(light.Edge1, light.Edge2)
+
+	diffuse, highlight := 0., 0.
+	for _, offset := range offsets {
+		var sample Vec3
+		switch light.Type {
+		case Rectangle:
+			sample = vector3.Add(center, vector3.Add(light.Edge1.MulScalar(offset[0]-0.5), light.Edge2.MulScalar(offset[1]-0.5)))
+			if vector3.Dot(front, vector3.Sub(point, sample)) <= 0 {
+				continue
+			}
+		default:
+			r := light.Radius * math.Sqrt(offset[0])
+			phi := 2 * math.Pi * offset[1]
+			sample = vector3.Add(center, vector3.Add(tangent.MulScalar(r*math.Cos(phi)), bitangent.MulScalar(r*math.Sin(phi))))
+			if light.Type == Disk && vector3.Dot(unitAxis, vector3.Sub(point, sample)) <= 0 {
+				continue
+			}
+		}
+		d, h := light.shine(vector3.Sub(sample, point), 1., point, normal, inverseDir, specular, occluders)
+		diffuse, highlight = diffuse+d, highlight+h
+	}
+	return diffuse / float64(samples), highlight / float64(samples)
+}
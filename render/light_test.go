@@ -0,0 +1,20 @@
+package render
+
+import "testing"
+
+func TestAreaLightsDoNotAllocate(t *testing.T) {
+	var occluders Accelerator = LinearScan{&Sphere{Center: Vec3{Y: 2, Z: 1}, Radius: 0.5, Surface: &Phong{Specular: -1}}}
+	lights := []Light{
+		{Type: Rectangle, Position: Vec3{Y: 4}, Edge1: Vec3{X: 1}, Edge2: Vec3{Z: 1}, Color: White, Intensity: 1},
+		{Type: Disk, Position: Vec3{Y: 4}, Direction: Vec3{Y: -1}, Radius: 1, Color: White, Intensity: 1, Samples: 32},
+		{Type: Spherical, Position: Vec3{Y: 4}, Radius: 1, Color: White, Intensity: 1, Samples: 64},
+	}
+	for _, light := range lights {
+		allocs := testing.AllocsPerRun(100, func() {
+			light.ComputeLighting(Vec3{}, Vec3{Y: 1}, Vec3{Y: 1, Z: -1}, 10, occluders)
+		})
+		if allocs != 0 {
+			t.Errorf("light type %d allocates %g times per shading point", light.Type, allocs)
+		}
+	}
+}
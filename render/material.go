@@ -0,0 +1,153 @@
+package render
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"raytracing/vector3"
+)
+
+// Material decides how a surface responds to light. Shapes only refer to a
+// material, so one material can be shared by any number of shapes.
+type Material interface {
+	// Shade returns the colour seen along the ray that produced hit.
+	// recursionDepth is the number of secondary bounces still allowed.
+	Shade(scene *Scene, hit *Hit, recursionDepth int8) RGB
+}
+
+// Hit describes where a ray met a shape.
+type Hit struct {
+	Shape Shape
+	Point Vec3
+	// Normal is the unit surface normal on the side the ray came from.
+	Normal Vec3
+	// Direction is the direction of the incoming ray.
+	Direction Vec3
+	// Entering is false when the ray hit the back of the surface, e.g. from
+	// the inside of a sphere.
+	Entering bool
+}
+
+// Phong is the classic material: Phong highlights on top of Lambert
+// diffuse lighting, plus mirror reflection and Fresnel-weighted refraction.
+type Phong struct {
+	Color RGB
+	// Specular is the Phong exponent, -1 disables highlights.
+	Specular   float64
+	Reflective float64
+	// Transparency is the share of light passing into the surface, which
+	// is then split between reflection and refraction by the Fresnel
+	// equations. RefractiveIndex is the index of refraction of the inside;
+	// zero means 1.
+	Transparency    float64
+	RefractiveIndex float64
+	// Texture, when set, is multiplied with Color.
+	Texture Texture
+	// SpecularTexture and ReflectiveTexture, when set, vary the strength of
+	// the highlights and of Reflective over the surface by their
+	// luminance, e.g. to make only the polished cells of a checkerboard
+	// shine.
+	SpecularTexture   Texture
+	ReflectiveTexture Texture
+}
+
+// albedo returns the diffuse colour of the surface at hit.
+func (m *Phong) albedo(hit *Hit) RGB {
+	if m.Texture == nil {
+		return m.Color
+	}
+	return m.Color.Mul(m.Texture.ColorAt(hit))
+}
+
+func (m *Phong) Shade(scene *Scene, hit *Hit, recursionDepth int8) RGB {
+	accel := scene.accelerator()
+	direction := hit.Direction
+	lightVal := Black
+	if m.SpecularTexture == nil {
+		for _, light := range scene.Lights {
+			lightVal = lightVal.Add(light.ComputeLighting(hit.Point, hit.Normal, direction.Negate(), m.Specular, accel))
+		}
+	} else {
+		strength := textureStrength(m.SpecularTexture, hit)
+		for _, light := range scene.Lights {
+			diffuse, highlight := light.ComputeLightingParts(hit.Point, hit.Normal, direction.Negate(), m.Specular, accel)
+			lightVal = lightVal.Add(diffuse).Add(highlight.MulScalar(strength))
+		}
+	}
+	localColor := m.albedo(hit).Mul(lightVal)
+	reflective := m.reflective(hit)
+	if (reflective <= 0 && m.Transparency <= 0) || recursionDepth <= 0 {
+		return localColor
+	}
+
+	reflectedRay := ReflectRay(direction.Negate(), hit.Normal)
+	tMin := Epsilon //Necessary offset for avoid intersection with itself
+	tMax := Infinity
+	reflectedColor := TraceRay(hit.Point, reflectedRay, scene, recursionDepth-1, tMin, tMax)
+
+	localColor = localColor.MulScalar(1 - reflective)
+	resColor := reflectedColor.MulScalar(reflective).Add(localColor)
+	if m.Transparency <= 0 {
+		return resColor
+	}
+
+	eta := m.eta(hit)
+	unitDir := direction.Normalize()
+	cosIncident := -vector3.Dot(unitDir, hit.Normal)
+	kr := Fresnel(cosIncident, eta)
+	glassColor := reflectedColor.MulScalar(kr)
+	if refractedRay, ok := RefractRay(unitDir, hit.Normal, eta); ok {
+		refractedColor := TraceRay(hit.Point, refractedRay, scene, recursionDepth-1, tMin, tMax)
+		glassColor = glassColor.Add(refractedColor.MulScalar(1 - kr))
+	}
+	return resColor.MulScalar(1 - m.Transparency).Add(glassColor.MulScalar(m.Transparency))
+}
+
+// reflective returns Reflective at hit.
+func (m *Phong) reflective(hit *Hit) float64 {
+	if m.ReflectiveTexture == nil {
+		return m.Reflective
+	}
+	return m.Reflective * textureStrength(m.ReflectiveTexture, hit)
+}
+
+// textureStrength reads a texture as a scalar between 0 and 1.
+func textureStrength(t Texture, hit *Hit) float64 {
+	return math.Min(1, math.Max(0, t.ColorAt(hit).Luminance()))
+}
+
+// eta returns the ratio of refractive indices for a ray crossing the
+// surface at hit, going from outside into the material or back out of it.
+func (m *Phong) eta(hit *Hit) float64 {
+	ior := m.RefractiveIndex
+	if ior <= 0 {
+		ior = 1
+	}
+	if !hit.Entering {
+		return ior
+	}
+	return 1 / ior
+}
+
+// Scatter follows the same split as Shade, choosing one branch at random:
+// glass with probability Transparency, then a mirror with probability
+// Reflective, otherwise a Lambertian bounce with Color as albedo. Phong
+// highlights have no part in it.
+func (m *Phong) Scatter(hit *Hit, rng *rand.Rand) (Vec3, RGB, bool) {
+	direction := hit.Direction.Normalize()
+	choice := rng.Float64()
+	if choice < m.Transparency {
+		eta := m.eta(hit)
+		kr := Fresnel(-vector3.Dot(direction, hit.Normal), eta)
+		if rng.Float64() >= kr {
+			if refracted, ok := RefractRay(direction, hit.Normal, eta); ok {
+				return refracted, White, false
+			}
+		}
+		return ReflectRay(direction.Negate(), hit.Normal), White, false
+	}
+	if m.Transparency < 1 && (choice-m.Transparency)/(1-m.Transparency) < m.reflective(hit) {
+		return ReflectRay(direction.Negate(), hit.Normal), White, false
+	}
+	return CosineHemisphere(hit.Normal, rng), m.albedo(hit), true
+}
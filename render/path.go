@@ -0,0 +1,116 @@
+package render
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"raytracing/vector3"
+)
+
+// DefaultRouletteDepth is the bounce from which PathTracer starts Russian
+// roulette when RouletteDepth is zero.
+const DefaultRouletteDepth = 3
+
+// PathTracer is a unidirectional Monte Carlo path tracer. Paths bounce off
+// diffuse surfaces in cosine-weighted random directions and pick up direct
+// light at every diffuse bounce by next-event estimation, i.e. by sampling
+// the lights with shadow rays. Ambient lights are ignored since indirect
+// light is traced for real; the background lights every escaping path.
+//
+// Paths are cut after the recursion depth of the render, and from
+// RouletteDepth on they are ended at random in proportion to how little
+// they still carry, with survivors weighted up to stay unbiased.
+//
+// Noise drops with the square root of the samples per pixel, so the path
+// tracer needs many more of them than the Whitted tracer.
+type PathTracer struct {
+	RouletteDepth int
+}
+
+// Scatterer is implemented by materials the path tracer can bounce off.
+// Paths ending on other materials take the colour their Shade method
+// returns without recursion.
+type Scatterer interface {
+	// Scatter picks the direction a path continues in after hit and the
+	// weight the light arriving from there is multiplied with, already
+	// divided by the probability of the choice. diffuse reports a bounce
+	// off the diffuse part of the surface, where weight is its albedo.
+	Scatter(hit *Hit, rng *rand.Rand) (direction Vec3, weight RGB, diffuse bool)
+}
+
+func (p PathTracer) Radiance(scene *Scene, startPoint Vec3, direction Vec3, depth int, rng *rand.Rand) RGB {
+	if !ValidRay(startPoint, direction) {
+		return Black
+	}
+	rouletteDepth := p.RouletteDepth
+	if rouletteDepth <= 0 {
+		rouletteDepth = DefaultRouletteDepth
+	}
+	radiance, throughput := Black, White
+	for bounce := 0; ; bounce++ {
+		hit, ok := scene.intersect(startPoint, direction, Epsilon, Infinity)
+		if !ok {
+			return radiance.Add(throughput.Mul(scene.Background))
+		}
+		material := hit.Shape.Material()
+		scatterer, ok := material.(Scatterer)
+		if !ok {
+			return radiance.Add(throughput.Mul(material.Shade(scene, &hit, 0)))
+		}
+
+		next, weight, diffuse := scatterer.Scatter(&hit, rng)
+		if diffuse {
+			radiance = radiance.Add(throughput.Mul(weight).Mul(directLight(scene, &hit)))
+		}
+		throughput = throughput.Mul(weight)
+		if bounce >= depth {
+			return radiance
+		}
+		if bounce >= rouletteDepth {
+			survive := math.Min(0.95, math.Max(throughput.R, math.Max(throughput.G, throughput.B)))
+			if rng.Float64() >= survive {
+				return radiance
+			}
+			throughput = throughput.MulScalar(1 / survive)
+		}
+		startPoint, direction = hit.Point, next
+	}
+}
+
+// directLight sums the unshadowed diffuse light reaching hit from every
+// light but the ambient ones: point, spot, directional and area lights.
+func directLight(scene *Scene, hit *Hit) RGB {
+	accel := scene.accelerator()
+	light := Black
+	for i := range scene.Lights {
+		if scene.Lights[i].Type == Ambient {
+			continue
+		}
+		light = light.Add(scene.Lights[i].ComputeLighting(hit.Point, hit.Normal, hit.Direction.Negate(), -1, accel))
+	}
+	return light
+}
+
+// CosineHemisphere returns a random unit direction around the unit normal,
+// more likely the closer it is to the normal: the density is cos(θ)/π. With
+// it the cosine of diffuse reflection cancels out of the path weight.
+func CosineHemisphere(normal Vec3, rng *rand.Rand) Vec3 {
+	// Malley's method: uniform points on the disk projected up.
+	r := math.Sqrt(rng.Float64())
+	phi := 2 * math.Pi * rng.Float64()
+	x, y := r*math.Cos(phi), r*math.Sin(phi)
+	z := math.Sqrt(math.Max(0, 1-x*x-y*y))
+	tangent, bitangent := orthonormalBasis(normal)
+	return vector3.Add(vector3.Add(tangent.MulScalar(x), bitangent.MulScalar(y)), normal.MulScalar(z))
+}
+
+// orthonormalBasis returns two unit vectors perpendicular to the unit
+// normal and to each other (Duff et al., "Building an Orthonormal Basis,
+// Revisited").
+func orthonormalBasis(n Vec3) (Vec3, Vec3) {
+	sign := math.Copysign(1, n.Z)
+	a := -1 / (sign + n.Z)
+	b := n.X * n.Y * a
+	return Vec3{X: 1 + sign*n.X*n.X*a, Y: sign * b, Z: -sign * n.X},
+		Vec3{X: b, Y: sign + n.Y*n.Y*a, Z: -n.Y}
+}
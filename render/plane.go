@@ -0,0 +1,35 @@
+package render
+
+import (
+	"math"
+
+	"raytracing/vector3"
+)
+
+// Plane is an infinite plane through Point. Normal need not be unit length.
+type Plane struct {
+	Point   Vec3
+	Normal  Vec3
+	Surface Material
+}
+
+func (p *Plane) Intersect(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (float64, bool) {
+	denominator := vector3.Dot(p.Normal, direction)
+	// Rays running parallel to the plane never hit it.
+	if math.Abs(denominator) < 1e-12 {
+		return 0, false
+	}
+	t := vector3.Dot(vector3.Sub(p.Point, startPoint), p.Normal) / denominator
+	if t < tMin || t > tMax {
+		return 0, false
+	}
+	return t, true
+}
+
+func (p *Plane) NormalAt(point Vec3) Vec3 {
+	return p.Normal.Normalize()
+}
+
+func (p *Plane) Material() Material {
+	return p.Surface
+}
@@ -0,0 +1,224 @@
+package render
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"raytracing/vector3"
+)
+
+// Procedural textures are solid: they are functions of the point in space
+// rather than of UV coordinates, so they need no mapping and continue
+// seamlessly across shapes, as if the object were carved out of a block.
+// Each has an At method taking the point in scene coordinates and returns
+// a mix of the two colours A and B.
+
+// Checker alternates A and B in cubes of edge Size.
+type Checker struct {
+	A, B RGB
+	Size float64
+}
+
+func (t *Checker) ColorAt(hit *Hit) RGB { return t.At(hit.Point) }
+
+func (t *Checker) At(point Vec3) RGB {
+	// The nudge keeps surfaces lying exactly on a cube face, like a floor
+	// at y = -1, from flickering between cells due to rounding.
+	const nudge = 1e-6
+	cell := math.Floor(point.X/t.Size+nudge) + math.Floor(point.Y/t.Size+nudge) + math.Floor(point.Z/t.Size+nudge)
+	if math.Mod(cell, 2) == 0 {
+		return t.A
+	}
+	return t.B
+}
+
+// Stripes alternates A and B in slabs of the given Width across Axis.
+type Stripes struct {
+	A, B  RGB
+	Axis  Vec3
+	Width float64
+}
+
+func (t *Stripes) ColorAt(hit *Hit) RGB { return t.At(hit.Point) }
+
+func (t *Stripes) At(point Vec3) RGB {
+	axis := t.Axis.Normalize()
+	if math.Mod(math.Floor(vector3.Dot(point, axis)/t.Width), 2) == 0 {
+		return t.A
+	}
+	return t.B
+}
+
+// Gradient blends linearly from A at Start to B at End, and keeps the end
+// colours beyond them.
+type Gradient struct {
+	A, B       RGB
+	Start, End Vec3
+}
+
+func (t *Gradient) ColorAt(hit *Hit) RGB { return t.At(hit.Point) }
+
+func (t *Gradient) At(point Vec3) RGB {
+	span := vector3.Sub(t.End, t.Start)
+	length := vector3.Dot(span, span)
+	if length == 0 {
+		return t.A
+	}
+	return mix(t.A, t.B, vector3.Dot(vector3.Sub(point, t.Start), span)/length)
+}
+
+// Perlin is Ken Perlin's improved gradient noise. The same seed always
+// gives the same noise.
+type Perlin struct {
+	perm [512]uint8
+}
+
+func NewPerlin(seed uint64) *Perlin {
+	p := &Perlin{}
+	rng := rand.New(rand.NewPCG(seed, 0))
+	for i, v := range rng.Perm(256) {
+		p.perm[i] = uint8(v)
+		p.perm[i+256] = uint8(v)
+	}
+	return p
+}
+
+// Noise returns a smooth pseudo-random value between -1 and 1 that is
+// zero at every integer lattice point.
+func (p *Perlin) Noise(point Vec3) float64 {
+	fx, fy, fz := math.Floor(point.X), math.Floor(point.Y), math.Floor(point.Z)
+	x, y, z := point.X-fx, point.Y-fy, point.Z-fz
+	xi, yi, zi := int(fx)&255, int(fy)&255, int(fz)&255
+	u, v, w := fade(x), fade(y), fade(z)
+
+	perm := &p.perm
+	a := int(perm[xi]) + yi
+	aa, ab := int(perm[a])+zi, int(perm[a+1])+zi
+	b := int(perm[xi+1]) + yi
+	ba, bb := int(perm[b])+zi, int(perm[b+1])+zi
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(perm[aa], x, y, z), grad(perm[ba], x-1, y, z)),
+			lerp(u, grad(perm[ab], x, y-1, z), grad(perm[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(perm[aa+1], x, y, z-1), grad(perm[ba+1], x-1, y, z-1)),
+			lerp(u, grad(perm[ab+1], x, y-1, z-1), grad(perm[bb+1], x-1, y-1, z-1))))
+}
+
+// Turbulence sums the magnitude of octaves of noise, each at twice the
+// frequency and half the amplitude of the previous one. The result lies
+// between 0 and about 1.
+func (p *Perlin) Turbulence(point Vec3, octaves int) float64 {
+	sum, amplitude := 0., 1.
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * math.Abs(p.Noise(point))
+		point = point.MulScalar(2)
+		amplitude /= 2
+	}
+	return sum
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t float64, a float64, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad dots the offset with one of the twelve cube edge directions.
+func grad(hash uint8, x float64, y float64, z float64) float64 {
+	h := hash & 15
+	u, v := y, z
+	if h < 8 {
+		u = x
+	}
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// mix blends from a to b, with t clamped to [0, 1].
+func mix(a RGB, b RGB, t float64) RGB {
+	t = math.Min(1, math.Max(0, t))
+	return a.MulScalar(1 - t).Add(b.MulScalar(t))
+}
+
+// NoiseTexture blends A and B by plain Perlin noise. Scale is the
+// frequency: features are about 1/Scale across.
+type NoiseTexture struct {
+	A, B  RGB
+	Noise *Perlin
+	Scale float64
+}
+
+func (t *NoiseTexture) ColorAt(hit *Hit) RGB { return t.At(hit.Point) }
+
+func (t *NoiseTexture) At(point Vec3) RGB {
+	return mix(t.A, t.B, 0.5*(1+t.Noise.Noise(point.MulScalar(t.Scale))))
+}
+
+// Turbulence blends A and B by turbulent noise, which looks like smoke or
+// clouds.
+type Turbulence struct {
+	A, B    RGB
+	Noise   *Perlin
+	Scale   float64
+	Octaves int
+}
+
+func (t *Turbulence) ColorAt(hit *Hit) RGB { return t.At(hit.Point) }
+
+func (t *Turbulence) At(point Vec3) RGB {
+	return mix(t.A, t.B, t.Noise.Turbulence(point.MulScalar(t.Scale), t.Octaves))
+}
+
+// Marble is a sine wave along X, from A to B, whose phase is distorted by
+// turbulence into veins. Strength sets how much the veins wander.
+type Marble struct {
+	A, B     RGB
+	Noise    *Perlin
+	Scale    float64
+	Octaves  int
+	Strength float64
+}
+
+func (t *Marble) ColorAt(hit *Hit) RGB { return t.At(hit.Point) }
+
+func (t *Marble) At(point Vec3) RGB {
+	p := point.MulScalar(t.Scale)
+	phase := p.X + t.Strength*t.Noise.Turbulence(p, t.Octaves)
+	return mix(t.A, t.B, 0.5*(1+math.Sin(math.Pi*phase)))
+}
+
+// Wood is rings around the vertical axis through Center, Scale rings per
+// unit of distance, going from A at the start of each ring to B. Strength
+// is how many rings turbulence may shift them by.
+type Wood struct {
+	A, B     RGB
+	Noise    *Perlin
+	Center   Vec3
+	Scale    float64
+	Octaves  int
+	Strength float64
+}
+
+func (t *Wood) ColorAt(hit *Hit) RGB { return t.At(hit.Point) }
+
+func (t *Wood) At(point Vec3) RGB {
+	d := vector3.Sub(point, t.Center)
+	rings := math.Hypot(d.X, d.Z)*t.Scale + t.Strength*t.Noise.Turbulence(d, t.Octaves)
+	ring := rings - math.Floor(rings)
+	// Sharpen the rings: mostly early wood with a narrow band of late wood.
+	return mix(t.A, t.B, ring*ring*ring)
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress describes how far a render has come. It is passed to
+// Options.Progress after every finished tile.
+type Progress struct {
+	TilesDone  int
+	TilesTotal int
+	Elapsed    time.Duration
+	// ETA estimates the remaining time from the average pace so far.
+	ETA time.Duration
+	// Rays counts the camera rays traced so far.
+	Rays          uint64
+	RaysPerSecond float64
+}
+
+// Fraction returns the finished part of the render, from 0 to 1.
+func (p Progress) Fraction() float64 {
+	if p.TilesTotal == 0 {
+		return 1
+	}
+	return float64(p.TilesDone) / float64(p.TilesTotal)
+}
+
+// progressTracker serializes progress reports from the workers, so the
+// callback never runs concurrently and always sees increasing counts.
+type progressTracker struct {
+	mu       sync.Mutex
+	report   func(Progress)
+	started  time.Time
+	progress Progress
+}
+
+func newProgressTracker(report func(Progress), tiles int, started time.Time) *progressTracker {
+	return &progressTracker{report: report, started: started, progress: Progress{TilesTotal: tiles}}
+}
+
+// tileDone records a finished tile that traced the given number of rays.
+func (t *progressTracker) tileDone(rays uint64) {
+	if t.report == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := &t.progress
+	p.TilesDone++
+	p.Rays += rays
+	p.Elapsed = time.Since(t.started)
+	if seconds := p.Elapsed.Seconds(); seconds > 0 {
+		p.RaysPerSecond = float64(p.Rays) / seconds
+	}
+	remaining := p.TilesTotal - p.TilesDone
+	p.ETA = time.Duration(float64(p.Elapsed) / float64(p.TilesDone) * float64(remaining))
+	t.report(*p)
+}
@@ -0,0 +1,182 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options control a single render. Zero Threads means one worker per CPU
+// and zero Samples one sample per pixel.
+type Options struct {
+	Width          int
+	Height         int
+	RecursionDepth int
+	Threads        int
+	ToneMapping    ToneMapping
+	Accelerator    AcceleratorKind
+	Samples        int
+	Pattern        SamplePattern
+	// Seed makes the random sample positions reproducible. Each pixel
+	// derives its own stream from it, so results do not depend on Threads.
+	Seed uint64
+	// TileSize is the edge length of the square tiles workers pull from
+	// the shared queue. Zero means DefaultTileSize.
+	TileSize int
+	// Stats, when not nil, receives the timings of the render.
+	Stats *RenderStats
+	// Integrator computes the light along camera rays. Nil means Whitted.
+	Integrator Integrator
+	// Progress, when not nil, is called after every finished tile. Calls
+	// come from the worker goroutines but never overlap, and should return
+	// quickly since they hold up the worker that finished the tile.
+	Progress func(Progress)
+}
+
+// Renderer traces scenes into images. The zero value is ready to use.
+type Renderer struct{}
+
+// Render traces the scene and tone maps the result to 8 bits per channel.
+// Like RenderHDR it returns the image along with a *RenderError when only
+// some pixels failed.
+func (r *Renderer) Render(ctx context.Context, scene *Scene, options Options) (*image.RGBA, error) {
+	img, err := r.RenderHDR(ctx, scene, options)
+	if img == nil {
+		return nil, err
+	}
+	return img.ToneMap(options.ToneMapping), err
+}
+
+// RenderHDR traces the scene into a linear floating-point image. The scene
+// is validated first. Cancelling ctx stops the workers within a row of
+// pixels and returns the context's error. Pixels that cannot be traced are
+// left black and reported together in a *RenderError, which is returned
+// with the image.
+func (r *Renderer) RenderHDR(ctx context.Context, scene *Scene, options Options) (*HDRImage, error) {
+	if scene == nil {
+		return nil, errors.New("render: nil scene")
+	}
+	if options.Width <= 0 || options.Height <= 0 {
+		return nil, errors.New("render: image size must be positive")
+	}
+	if options.RecursionDepth < 0 || options.RecursionDepth > math.MaxInt8 {
+		return nil, errors.New("render: recursion depth out of range")
+	}
+	if options.TileSize < 0 {
+		return nil, errors.New("render: tile size must not be negative")
+	}
+	if err := scene.Validate(); err != nil {
+		return nil, err
+	}
+	threads := options.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	samples := max(options.Samples, 1)
+	integrator := options.Integrator
+	if integrator == nil {
+		integrator = Whitted{}
+	}
+
+	// Work on a copy so the caller's scene is never modified.
+	prepared := *scene
+	prepared.accel = NewAccelerator(options.Accelerator, scene.Shapes)
+	scene = &prepared
+
+	w, h := options.Width, options.Height
+	img := NewHDRImage(w, h)
+	sampler := ImageSampler{Width: w, Height: h}
+	camera := scene.Camera.frame(sampler.Aspect())
+
+	tileSize := options.TileSize
+	if tileSize == 0 {
+		tileSize = DefaultTileSize
+	}
+	tiles := splitTiles(w, h, tileSize)
+	timings := make([]TileStats, len(tiles))
+	// Workers pull tiles from the queue, so cheap and expensive regions of
+	// the image even out across them.
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	// Every worker collects its own failures; they are merged at the end.
+	failures := make([]RenderError, threads)
+	started := time.Now()
+	progress := newProgressTracker(options.Progress, len(tiles), started)
+
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			failed := &failures[i]
+			pcg := rand.NewPCG(0, 0)
+			rng := rand.New(pcg)
+			offsets := make([][2]float64, samples)
+			for {
+				t := int(next.Add(1) - 1)
+				if t >= len(tiles) || ctx.Err() != nil {
+					return
+				}
+				tileStart := time.Now()
+				tile := tiles[t]
+				for y := tile.Min.Y; y < tile.Max.Y; y++ {
+					if ctx.Err() != nil {
+						return
+					}
+					for x := tile.Min.X; x < tile.Max.X; x++ {
+						pcg.Seed(uint64(y)*uint64(w)+uint64(x), options.Seed)
+						options.Pattern.Offsets(offsets, rng)
+						clr := Black
+						var err error
+						for _, offset := range offsets {
+							u, v := sampler.ScreenPoint(x, y, offset[0], offset[1])
+							start, rayDirection := camera.ray(u, v)
+							if !ValidRay(start, rayDirection) {
+								err = ErrDegenerateRay
+								break
+							}
+							clr = clr.Add(integrator.Radiance(scene, start, rayDirection, options.RecursionDepth, rng))
+						}
+						clr = clr.MulScalar(1 / float64(samples))
+						if err == nil && !clr.IsFinite() {
+							err = ErrNonFinite
+						}
+						if err != nil {
+							failed.add(&PixelError{X: x, Y: y, Err: err})
+							clr = Black
+						}
+						img.SetRGB(x, y, clr)
+					}
+				}
+				timings[t] = TileStats{Bounds: tile, Worker: i, Duration: time.Since(tileStart)}
+				progress.tileDone(uint64(tile.Dx()*tile.Dy()) * uint64(samples))
+			}
+		}(i)
+	}
+	wg.Wait()
+	if options.Stats != nil {
+		options.Stats.Elapsed = time.Since(started)
+		options.Stats.Tiles = options.Stats.Tiles[:0]
+		for _, timing := range timings {
+			if !timing.Bounds.Empty() {
+				options.Stats.Tiles = append(options.Stats.Tiles, timing)
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var failed RenderError
+	for i := range failures {
+		failed.merge(&failures[i])
+	}
+	if failed.Count > 0 {
+		return img, &failed
+	}
+	return img, nil
+}
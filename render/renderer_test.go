@@ -0,0 +1,187 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// palette is a value material that is not comparable, so it cannot be
+// used as a map key.
+type palette struct {
+	colors []RGB
+}
+
+func (p palette) Shade(scene *Scene, hit *Hit, recursionDepth int8) RGB {
+	return p.colors[0]
+}
+
+func testScene() *Scene {
+	return &Scene{
+		Shapes: []Shape{
+			&Sphere{Center: Vec3{Z: 3}, Radius: 1, Surface: &Phong{Color: White, Specular: -1}},
+		},
+		Lights: []Light{{Type: Ambient, Color: White, Intensity: 1}},
+		Camera: DefaultCamera(),
+	}
+}
+
+func TestRenderNonComparableMaterial(t *testing.T) {
+	scene := testScene()
+	material := palette{colors: []RGB{{R: 1}}}
+	scene.Shapes = []Shape{
+		&Sphere{Center: Vec3{X: -1, Z: 3}, Radius: 1, Surface: material},
+		&Sphere{Center: Vec3{X: 1, Z: 3}, Radius: 1, Surface: material},
+	}
+	var renderer Renderer
+	img, err := renderer.Render(context.Background(), scene, Options{Width: 8, Height: 8})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if c := img.RGBAAt(4, 4); c.R != 255 || c.G != 0 {
+		t.Errorf("centre pixel = %v, want red", c)
+	}
+}
+
+func TestRenderValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		spoil  func(scene *Scene)
+		object string
+	}{
+		{
+			name:   "zero radius",
+			spoil:  func(scene *Scene) { scene.Shapes[0].(*Sphere).Radius = 0 },
+			object: "shapes[0]",
+		},
+		{
+			name:   "NaN centre",
+			spoil:  func(scene *Scene) { scene.Shapes[0].(*Sphere).Center.X = math.NaN() },
+			object: "shapes[0]",
+		},
+		{
+			name:   "negative light intensity",
+			spoil:  func(scene *Scene) { scene.Lights[0].Intensity = -1 },
+			object: "lights[0]",
+		},
+	}
+	var renderer Renderer
+	for _, test := range tests {
+		scene := testScene()
+		test.spoil(scene)
+		img, err := renderer.Render(context.Background(), scene, Options{Width: 4, Height: 4})
+		if img != nil {
+			t.Errorf("%s: Render() returned an image", test.name)
+		}
+		if _, ok := err.(interface{ Unwrap() []error }); !ok {
+			t.Errorf("%s: Render() error = %#v, want joined errors", test.name, err)
+		}
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) || invalid.Object != test.object {
+			t.Errorf("%s: Render() error = %v, want a *ValidationError for %s", test.name, err, test.object)
+		}
+	}
+}
+
+func TestRenderPixelErrors(t *testing.T) {
+	scene := testScene()
+	// The forward vector overflows, so every camera ray comes out NaN.
+	scene.Camera.Position = Vec3{Z: -1e308}
+	scene.Camera.LookAt = Vec3{Z: 1e308}
+	const w, h = 6, 5
+	var renderer Renderer
+	img, err := renderer.Render(context.Background(), scene, Options{Width: w, Height: h})
+	if img == nil {
+		t.Fatalf("Render() returned no image, error = %v", err)
+	}
+	var failed *RenderError
+	if !errors.As(err, &failed) {
+		t.Fatalf("Render() error = %v, want a *RenderError", err)
+	}
+	if failed.Count != w*h {
+		t.Errorf("Count = %d, want %d", failed.Count, w*h)
+	}
+	if len(failed.Pixels) != maxPixelErrors {
+		t.Errorf("len(Pixels) = %d, want %d", len(failed.Pixels), maxPixelErrors)
+	}
+	seen := make(map[[2]int]bool)
+	for _, p := range failed.Pixels {
+		if p.X < 0 || p.X >= w || p.Y < 0 || p.Y >= h || seen[[2]int{p.X, p.Y}] {
+			t.Errorf("unexpected pixel (%d, %d)", p.X, p.Y)
+		}
+		seen[[2]int{p.X, p.Y}] = true
+		if !errors.Is(p, ErrDegenerateRay) {
+			t.Errorf("pixel (%d, %d) error = %v, want %v", p.X, p.Y, p.Err, ErrDegenerateRay)
+		}
+		if c := img.RGBAAt(p.X, p.Y); c.R != 0 || c.G != 0 || c.B != 0 {
+			t.Errorf("pixel (%d, %d) = %v, want black", p.X, p.Y, c)
+		}
+	}
+	if !errors.Is(err, ErrDegenerateRay) {
+		t.Errorf("errors.Is(%v, ErrDegenerateRay) = false", err)
+	}
+}
+
+func TestRenderCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var renderer Renderer
+	img, err := renderer.Render(ctx, testScene(), Options{Width: 64, Height: 64})
+	if img != nil || err != context.Canceled {
+		t.Errorf("Render() = %p, %v, want nil, %v", img, err, context.Canceled)
+	}
+}
+
+func TestRenderDeadline(t *testing.T) {
+	// A billion samples take minutes; the deadline must cut that short.
+	options := Options{Width: 2000, Height: 2000, Samples: 256}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var renderer Renderer
+	started := time.Now()
+	img, err := renderer.RenderHDR(ctx, testScene(), options)
+	elapsed := time.Since(started)
+	if img != nil || err != context.DeadlineExceeded {
+		t.Errorf("RenderHDR() = %p, %v, want nil, %v", img, err, context.DeadlineExceeded)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("RenderHDR() took %v after a 50ms deadline", elapsed)
+	}
+}
+
+func TestRenderProgress(t *testing.T) {
+	var reports []Progress
+	options := Options{
+		Width:    100,
+		Height:   70,
+		TileSize: 16,
+		Threads:  4,
+		Samples:  2,
+		Progress: func(p Progress) { reports = append(reports, p) },
+	}
+	var renderer Renderer
+	if _, err := renderer.RenderHDR(context.Background(), testScene(), options); err != nil {
+		t.Fatalf("RenderHDR() error = %v", err)
+	}
+	const tiles = 7 * 5
+	if len(reports) != tiles {
+		t.Fatalf("got %d progress reports, want %d", len(reports), tiles)
+	}
+	for i, p := range reports {
+		if p.TilesDone != i+1 || p.TilesTotal != tiles {
+			t.Errorf("report %d: %d of %d tiles done, want %d of %d", i, p.TilesDone, p.TilesTotal, i+1, tiles)
+		}
+	}
+	last := reports[len(reports)-1]
+	if last.ETA != 0 {
+		t.Errorf("final ETA = %v, want 0", last.ETA)
+	}
+	if last.Rays != 100*70*2 {
+		t.Errorf("final Rays = %d, want %d", last.Rays, 100*70*2)
+	}
+	if last.Fraction() != 1 {
+		t.Errorf("final Fraction() = %v, want 1", last.Fraction())
+	}
+}
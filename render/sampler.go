@@ -0,0 +1,162 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// ImageSampler maps image pixels to the screen coordinates cameras take.
+//
+// Pixel (0, 0) is the top-left corner of the image, x grows to the right and
+// y grows down. A pixel covers the unit square [x, x+1) x [y, y+1), so its
+// center is at offset (0.5, 0.5). Screen coordinates run from -1 at the left
+// edge to 1 at the right edge for u, and from -1 at the bottom edge to 1 at
+// the top edge for v.
+type ImageSampler struct {
+	Width  int
+	Height int
+}
+
+// ScreenPoint returns the screen coordinates of the point at offset
+// (dx, dy) inside pixel (x, y).
+func (s ImageSampler) ScreenPoint(x int, y int, dx float64, dy float64) (float64, float64) {
+	u := (float64(x)+dx)*2/float64(s.Width) - 1
+	v := 1 - (float64(y)+dy)*2/float64(s.Height)
+	return u, v
+}
+
+// Aspect is the width to height ratio cameras use to avoid stretching.
+func (s ImageSampler) Aspect() float64 {
+	return float64(s.Width) / float64(s.Height)
+}
+
+// SamplePattern decides where the samples of a pixel are placed.
+type SamplePattern int
+
+const (
+	// JitteredPattern places one random sample in each cell of a grid.
+	JitteredPattern SamplePattern = iota
+	// GridPattern places samples at the centers of a regular grid.
+	GridPattern
+	// RandomPattern places samples uniformly at random.
+	RandomPattern
+	// HaltonPattern uses the Halton sequence in bases 2 and 3.
+	HaltonPattern
+	// SobolPattern uses the first two dimensions of the Sobol sequence.
+	SobolPattern
+)
+
+var samplePatternNames = map[SamplePattern]string{
+	JitteredPattern: "jittered",
+	GridPattern:     "grid",
+	RandomPattern:   "random",
+	HaltonPattern:   "halton",
+	SobolPattern:    "sobol",
+}
+
+func (p SamplePattern) String() string {
+	if name, ok := samplePatternNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("SamplePattern(%d)", int(p))
+}
+
+func ParseSamplePattern(name string) (SamplePattern, error) {
+	for pattern, patternName := range samplePatternNames {
+		if patternName == name {
+			return pattern, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sample pattern %q", name)
+}
+
+// Offsets fills offsets with sample positions inside a pixel, each in
+// [0, 1) x [0, 1). A single sample always sits at the pixel center. Grid
+// and jittered patterns split the pixel into rows of cells of equal area,
+// one per sample; when the count is not a square number some rows get one
+// cell more and are taller, which keeps the samples centred on the pixel.
+// The low-discrepancy patterns are shifted by a random
+// amount so neighbouring pixels do not share the same positions.
+func (p SamplePattern) Offsets(offsets [][2]float64, rng *rand.Rand) {
+	n := len(offsets)
+	if n == 1 {
+		offsets[0] = [2]float64{0.5, 0.5}
+		return
+	}
+	switch p {
+	case GridPattern:
+		stratify(offsets, nil)
+	case JitteredPattern:
+		stratify(offsets, rng.Float64)
+	case RandomPattern:
+		for i := range offsets {
+			offsets[i] = [2]float64{rng.Float64(), rng.Float64()}
+		}
+	case HaltonPattern, SobolPattern:
+		shiftX, shiftY := rng.Float64(), rng.Float64()
+		for i := range offsets {
+			var x, y float64
+			if p == HaltonPattern {
+				// Index 0 is the origin in both bases, so start at 1.
+				x, y = radicalInverse(uint32(i+1), 2), radicalInverse(uint32(i+1), 3)
+			} else {
+				x, y = radicalInverse(uint32(i), 2), sobol2(uint32(i))
+			}
+			offsets[i] = [2]float64{math.Mod(x+shiftX, 1), math.Mod(y+shiftY, 1)}
+		}
+	}
+}
+
+// stratify places one sample in each of len(offsets) cells of equal area.
+// The cells are laid out in rows, some holding one cell more than others
+// when the count is not a square number, so the samples stay centred on the
+// pixel. jitter picks the position inside each cell, x then y; nil puts
+// samples at the cell centers.
+func stratify(offsets [][2]float64, jitter func() float64) {
+	n := len(offsets)
+	rows := int(math.Sqrt(float64(n)))
+	cols, extra := n/rows, n%rows
+	i := 0
+	for row := 0; row < rows; row++ {
+		count := cols
+		if row < extra {
+			count++
+		}
+		// The row covers the share of the pixel height its samples need
+		// to get cells of area 1/n.
+		top := i
+		for col := 0; col < count; col++ {
+			dx, dy := 0.5, 0.5
+			if jitter != nil {
+				dx, dy = jitter(), jitter()
+			}
+			offsets[i] = [2]float64{(float64(col) + dx) / float64(count), (float64(top) + dy*float64(count)) / float64(n)}
+			i++
+		}
+	}
+}
+
+// radicalInverse mirrors the digits of i in the given base around the
+// radix point, which is the i-th point of the van der Corput sequence.
+func radicalInverse(i uint32, base uint32) float64 {
+	inverse, scale := 0., 1/float64(base)
+	for f := scale; i > 0; i /= base {
+		inverse += float64(i%base) * f
+		f *= scale
+	}
+	return inverse
+}
+
+// sobol2 is the second dimension of the Sobol sequence, whose direction
+// numbers follow Pascal's triangle modulo 2.
+func sobol2(i uint32) float64 {
+	var r uint32
+	for v := uint32(1 << 31); i != 0; i >>= 1 {
+		if i&1 != 0 {
+			r ^= v
+		}
+		v ^= v >> 1
+	}
+	return float64(r) / (1 << 32)
+}
@@ -0,0 +1,110 @@
+package render
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestScreenPoint(t *testing.T) {
+	s := ImageSampler{Width: 300, Height: 100}
+	tests := []struct {
+		x, y   int
+		dx, dy float64
+		u, v   float64
+	}{
+		{0, 0, 0, 0, -1, 1},
+		{299, 0, 1, 0, 1, 1},
+		{0, 99, 0, 1, -1, -1},
+		{299, 99, 1, 1, 1, -1},
+		{150, 50, 0, 0, 0, 0},
+		{0, 0, 0.5, 0.5, -1 + 1./300, 1 - 1./100},
+	}
+	for _, tt := range tests {
+		u, v := s.ScreenPoint(tt.x, tt.y, tt.dx, tt.dy)
+		if !near(u, tt.u) || !near(v, tt.v) {
+			t.Errorf("ScreenPoint(%d, %d, %g, %g) = (%g, %g), want (%g, %g)", tt.x, tt.y, tt.dx, tt.dy, u, v, tt.u, tt.v)
+		}
+	}
+	if aspect := s.Aspect(); aspect != 3 {
+		t.Errorf("Aspect() = %g, want 3", aspect)
+	}
+}
+
+// TestOrientation renders a wide image with a red sphere up and to the left
+// and a blue one down and to the right, and checks that they show up there.
+func TestOrientation(t *testing.T) {
+	const width, height = 300, 100
+	red := &Phong{Color: RGB{R: 1}, Specular: -1}
+	blue := &Phong{Color: RGB{B: 1}, Specular: -1}
+	scene := &Scene{
+		Shapes: []Shape{
+			// The vertical field of view of 90 degrees spans y in [-4, 4]
+			// and x in [-12, 12] at z = 4.
+			&Sphere{Center: Vec3{X: -6, Y: 2, Z: 4}, Radius: 1, Surface: red},
+			&Sphere{Center: Vec3{X: 6, Y: -2, Z: 4}, Radius: 1, Surface: blue},
+		},
+		Lights: []Light{{Type: Ambient, Color: White, Intensity: 1}},
+		Camera: DefaultCamera(),
+	}
+	var renderer Renderer
+	img, err := renderer.RenderHDR(context.Background(), scene, Options{Width: width, Height: height})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var redCount, blueCount int
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := img.RGBAt(x, y)
+			switch {
+			case c.R > 0.5:
+				redCount++
+				if x >= width/2 || y >= height/2 {
+					t.Errorf("red pixel at (%d, %d), want it in the top-left quadrant", x, y)
+				}
+			case c.B > 0.5:
+				blueCount++
+				if x < width/2 || y < height/2 {
+					t.Errorf("blue pixel at (%d, %d), want it in the bottom-right quadrant", x, y)
+				}
+			}
+		}
+	}
+	if redCount == 0 || blueCount == 0 {
+		t.Fatalf("found %d red and %d blue pixels, want both spheres visible", redCount, blueCount)
+	}
+	// The centers land where the camera model projects them.
+	if c := img.RGBAt(75, 25); c.R <= 0.5 {
+		t.Errorf("pixel (75, 25) = %v, want the center of the red sphere", c)
+	}
+	if c := img.RGBAt(225, 75); c.B <= 0.5 {
+		t.Errorf("pixel (225, 75) = %v, want the center of the blue sphere", c)
+	}
+}
+
+func near(a float64, b float64) bool {
+	const tolerance = 1e-9
+	return a-b < tolerance && b-a < tolerance
+}
+
+func TestOffsetsCentred(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for n := 1; n <= 20; n++ {
+		for _, pattern := range []SamplePattern{GridPattern, JitteredPattern} {
+			offsets := make([][2]float64, n)
+			pattern.Offsets(offsets, rng)
+			var sumX, sumY float64
+			for _, o := range offsets {
+				if o[0] < 0 || o[0] >= 1 || o[1] < 0 || o[1] >= 1 {
+					t.Errorf("%v with %d samples: offset %v outside the pixel", pattern, n, o)
+				}
+				sumX += o[0]
+				sumY += o[1]
+			}
+			if pattern == GridPattern && (!near(sumX/float64(n), 0.5) || !near(sumY/float64(n), 0.5)) {
+				t.Errorf("grid with %d samples has mean (%g, %g), want (0.5, 0.5)", n, sumX/float64(n), sumY/float64(n))
+			}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package render
+
+type Scene struct {
+	Shapes     []Shape
+	Lights     []Light
+	Background RGB
+	Camera     Camera
+
+	// accel is set on the copy of the scene a render works with.
+	accel Accelerator
+}
+
+func (s *Scene) accelerator() Accelerator {
+	if s.accel != nil {
+		return s.accel
+	}
+	return LinearScan(s.Shapes)
+}
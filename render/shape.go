@@ -0,0 +1,26 @@
+package render
+
+import "math"
+
+type Shape interface {
+	// Intersect returns the smallest ray parameter t within [tMin, tMax]
+	// at which startPoint + t*direction hits the shape.
+	Intersect(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (float64, bool)
+	// NormalAt returns the unit outward normal at a point on the surface.
+	NormalAt(point Vec3) Vec3
+	Material() Material
+}
+
+// FindClosest returns the nearest shape hit by the ray, or nil if there is none.
+func FindClosest(startPoint Vec3, direction Vec3, shapes []Shape, tMin float64, tMax float64) (Shape, float64) {
+	closestT := math.MaxFloat64
+	var closestShape Shape
+
+	for _, shape := range shapes {
+		if t, ok := shape.Intersect(startPoint, direction, tMin, tMax); ok && t < closestT {
+			closestShape = shape
+			closestT = t
+		}
+	}
+	return closestShape, closestT
+}
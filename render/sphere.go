@@ -0,0 +1,70 @@
+package render
+
+import (
+	"math"
+
+	"raytracing/vector3"
+)
+
+type Sphere struct {
+	Radius  float64
+	Center  Vec3
+	Surface Material
+}
+
+func (s *Sphere) ComputeIntersection(startPoint Vec3, direction Vec3) (float64, float64) {
+	oc := startPoint.Sub(s.Center)
+	a := vector3.Dot(direction, direction)
+	// A ray without direction hits nothing.
+	if a == 0.0 {
+		return -1., -1.
+	}
+	b := 2 * vector3.Dot(oc, direction)
+	c := vector3.Dot(oc, oc) - s.Radius*s.Radius
+
+	//intersection equantion of a^2 + 2b + c
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return -1., -1.
+	}
+	t1 := (-b + math.Sqrt(discriminant)) / (2 * a)
+	t2 := (-b - math.Sqrt(discriminant)) / (2 * a)
+	return t1, t2
+}
+
+func (s *Sphere) Intersect(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (float64, bool) {
+	t1, t2 := s.ComputeIntersection(startPoint, direction)
+	if t1 > t2 {
+		t1, t2 = t2, t1
+	}
+	if t1 >= tMin && t1 <= tMax {
+		return t1, true
+	}
+	if t2 >= tMin && t2 <= tMax {
+		return t2, true
+	}
+	return 0, false
+}
+
+func (s *Sphere) NormalAt(point Vec3) Vec3 {
+	// N = P - C
+	normal := vector3.Sub(point, s.Center)
+	return normal.Normalize()
+}
+
+func (s *Sphere) Material() Material {
+	return s.Surface
+}
+
+// UVAt maps the sphere like a globe: U runs once around the equator,
+// starting and ending at the back (+Z side) so that the middle of a texture
+// faces a camera looking down +Z, and V runs from the south pole to the
+// north pole.
+func (s *Sphere) UVAt(point Vec3) UV {
+	d := vector3.Sub(point, s.Center)
+	d = d.Normalize()
+	return UV{
+		U: 0.5 + math.Atan2(d.X, -d.Z)/(2*math.Pi),
+		V: 0.5 + math.Asin(math.Max(-1, math.Min(1, d.Y)))/math.Pi,
+	}
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Texture varies a material property over a surface.
+type Texture interface {
+	// ColorAt returns the texture colour where hit met the surface.
+	ColorAt(hit *Hit) RGB
+}
+
+// UVMapped is implemented by shapes with texture coordinates. Textures
+// looked up on other shapes see UV (0, 0).
+type UVMapped interface {
+	UVAt(point Vec3) UV
+}
+
+// UV returns the texture coordinates of the hit point.
+func (h *Hit) UV() UV {
+	if mapped, ok := h.Shape.(UVMapped); ok {
+		return mapped.UVAt(h.Point)
+	}
+	return UV{}
+}
+
+// WrapMode decides what lies outside the [0, 1] UV range of an image.
+type WrapMode int
+
+const (
+	// WrapRepeat tiles the image.
+	WrapRepeat WrapMode = iota
+	// WrapClamp extends the edge texels.
+	WrapClamp
+	// WrapMirror tiles the image, flipping every other copy.
+	WrapMirror
+)
+
+func ParseWrapMode(name string) (WrapMode, error) {
+	switch name {
+	case "repeat":
+		return WrapRepeat, nil
+	case "clamp":
+		return WrapClamp, nil
+	case "mirror":
+		return WrapMirror, nil
+	}
+	return 0, fmt.Errorf("unknown wrap mode %q", name)
+}
+
+// texel maps a texel index to one inside [0, n).
+func (w WrapMode) texel(i int, n int) int {
+	switch w {
+	case WrapClamp:
+		return min(max(i, 0), n-1)
+	case WrapMirror:
+		i %= 2 * n
+		if i < 0 {
+			i += 2 * n
+		}
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+		return i
+	}
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
+
+// ImageTexture maps an image onto a surface through its UV coordinates,
+// with U growing to the right of the image and V growing up, as in OBJ
+// files. Texels are filtered bilinearly unless Nearest is set.
+type ImageTexture struct {
+	Image   *HDRImage
+	Wrap    WrapMode
+	Nearest bool
+}
+
+// NewImageTexture converts img to linear colour for use as a texture. The
+// image is assumed to be sRGB encoded, like virtually all PNG and JPEG
+// files.
+func NewImageTexture(img image.Image) *ImageTexture {
+	bounds := img.Bounds()
+	hdr := NewHDRImage(bounds.Dx(), bounds.Dy())
+	for y := 0; y < hdr.Height; y++ {
+		for x := 0; x < hdr.Width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			hdr.SetRGB(x, y, RGB{
+				R: SRGBToLinear(float64(r) / 0xffff),
+				G: SRGBToLinear(float64(g) / 0xffff),
+				B: SRGBToLinear(float64(b) / 0xffff),
+			})
+		}
+	}
+	return &ImageTexture{Image: hdr}
+}
+
+func (t *ImageTexture) ColorAt(hit *Hit) RGB {
+	return t.Lookup(hit.UV())
+}
+
+// Lookup returns the filtered texture colour at uv.
+func (t *ImageTexture) Lookup(uv UV) RGB {
+	w, h := t.Image.Width, t.Image.Height
+	if w == 0 || h == 0 {
+		return Black
+	}
+	// Texel centers sit at half-integer positions.
+	x := uv.U*float64(w) - 0.5
+	y := (1-uv.V)*float64(h) - 0.5
+	if t.Nearest {
+		return t.texel(int(math.Floor(x+0.5)), int(math.Floor(y+0.5)))
+	}
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	top := t.texel(ix, iy).MulScalar(1 - fx).Add(t.texel(ix+1, iy).MulScalar(fx))
+	bottom := t.texel(ix, iy+1).MulScalar(1 - fx).Add(t.texel(ix+1, iy+1).MulScalar(fx))
+	return top.MulScalar(1 - fy).Add(bottom.MulScalar(fy))
+}
+
+func (t *ImageTexture) texel(x int, y int) RGB {
+	return t.Image.RGBAt(t.Wrap.texel(x, t.Image.Width), t.Wrap.texel(y, t.Image.Height))
+}
@@ -0,0 +1,104 @@
+package render
+
+import "testing"
+
+func TestWrapModeTexel(t *testing.T) {
+	const n = 4
+	tests := []struct {
+		mode WrapMode
+		i    int
+		want int
+	}{
+		{WrapRepeat, 0, 0},
+		{WrapRepeat, 3, 3},
+		{WrapRepeat, 4, 0},
+		{WrapRepeat, 9, 1},
+		{WrapRepeat, -1, 3},
+		{WrapRepeat, -4, 0},
+		{WrapRepeat, -5, 3},
+		{WrapClamp, 2, 2},
+		{WrapClamp, 4, 3},
+		{WrapClamp, 100, 3},
+		{WrapClamp, -1, 0},
+		{WrapClamp, -100, 0},
+		{WrapMirror, 2, 2},
+		{WrapMirror, 4, 3},
+		{WrapMirror, 7, 0},
+		{WrapMirror, 8, 0},
+		{WrapMirror, 9, 1},
+		{WrapMirror, -1, 0},
+		{WrapMirror, -2, 1},
+		{WrapMirror, -5, 3},
+		{WrapMirror, -8, 0},
+		{WrapMirror, -9, 0},
+	}
+	for _, test := range tests {
+		if got := test.mode.texel(test.i, n); got != test.want {
+			t.Errorf("WrapMode(%d).texel(%d, %d) = %d, want %d", test.mode, test.i, n, got, test.want)
+		}
+	}
+}
+
+// indexTexture returns a w×h texture whose texels hold their own x and y
+// in the red and green channels.
+func indexTexture(w, h int) *ImageTexture {
+	img := NewHDRImage(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGB(x, y, RGB{R: float64(x), G: float64(y), B: 1})
+		}
+	}
+	return &ImageTexture{Image: img}
+}
+
+func TestImageTextureTexelCentres(t *testing.T) {
+	// Powers of two keep the texel centres exact in UV space.
+	const w, h = 4, 2
+	for _, nearest := range []bool{false, true} {
+		for _, mode := range []WrapMode{WrapRepeat, WrapClamp, WrapMirror} {
+			texture := indexTexture(w, h)
+			texture.Wrap, texture.Nearest = mode, nearest
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					uv := UV{U: (float64(x) + 0.5) / w, V: 1 - (float64(y)+0.5)/h}
+					want := RGB{R: float64(x), G: float64(y), B: 1}
+					if got := texture.Lookup(uv); got != want {
+						t.Errorf("wrap %d, nearest %v: Lookup(%v) = %v, want %v", mode, nearest, uv, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestImageTextureOrientation(t *testing.T) {
+	texture := indexTexture(4, 3)
+	texture.Wrap = WrapClamp
+	tests := []struct {
+		uv   UV
+		want RGB
+	}{
+		// V grows up, so V=1 is the first image row and V=0 the last.
+		{UV{U: 0.125, V: 1}, RGB{R: 0, G: 0, B: 1}},
+		{UV{U: 0.875, V: 1}, RGB{R: 3, G: 0, B: 1}},
+		{UV{U: 0.125, V: 0}, RGB{R: 0, G: 2, B: 1}},
+	}
+	for _, nearest := range []bool{false, true} {
+		texture.Nearest = nearest
+		for _, test := range tests {
+			if got := texture.Lookup(test.uv); got != test.want {
+				t.Errorf("nearest %v: Lookup(%v) = %v, want %v", nearest, test.uv, got, test.want)
+			}
+		}
+	}
+}
+
+func TestImageTextureBilinear(t *testing.T) {
+	texture := indexTexture(4, 2)
+	// Halfway between texel centres blends the neighbours evenly.
+	uv := UV{U: 0.25, V: 0.5}
+	want := RGB{R: 0.5, G: 0.5, B: 1}
+	if got := texture.Lookup(uv); got != want {
+		t.Errorf("Lookup(%v) = %v, want %v", uv, got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"image"
+	"time"
+)
+
+// DefaultTileSize is the tile edge length used when Options.TileSize is zero.
+const DefaultTileSize = 32
+
+// TileStats records how long one tile took to trace.
+type TileStats struct {
+	Bounds   image.Rectangle
+	Worker   int
+	Duration time.Duration
+}
+
+// RenderStats collects the timings of a render.
+type RenderStats struct {
+	Elapsed time.Duration
+	// Tiles holds the tiles in the order they were queued, left to right
+	// and top to bottom. Tiles never started because the render was
+	// cancelled are left out.
+	Tiles []TileStats
+}
+
+// Busy returns the total time the workers spent tracing tiles.
+func (s *RenderStats) Busy() time.Duration {
+	var busy time.Duration
+	for _, tile := range s.Tiles {
+		busy += tile.Duration
+	}
+	return busy
+}
+
+// Slowest returns the tile that took longest to trace.
+func (s *RenderStats) Slowest() (TileStats, bool) {
+	if len(s.Tiles) == 0 {
+		return TileStats{}, false
+	}
+	slowest := s.Tiles[0]
+	for _, tile := range s.Tiles[1:] {
+		if tile.Duration > slowest.Duration {
+			slowest = tile
+		}
+	}
+	return slowest, true
+}
+
+// splitTiles cuts a width x height image into size x size tiles, the ones
+// on the right and bottom edges possibly smaller.
+func splitTiles(width int, height int, size int) []image.Rectangle {
+	tiles := make([]image.Rectangle, 0, ((width+size-1)/size)*((height+size-1)/size))
+	for y := 0; y < height; y += size {
+		for x := 0; x < width; x += size {
+			tiles = append(tiles, image.Rect(x, y, min(x+size, width), min(y+size, height)))
+		}
+	}
+	return tiles
+}
@@ -0,0 +1,132 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// ToneMapper compresses linear HDR colour into the [0, 1] range.
+type ToneMapper interface {
+	Map(c RGB) RGB
+}
+
+// Clamp cuts every channel at 1.
+type Clamp struct{}
+
+func (Clamp) Map(c RGB) RGB {
+	return RGB{math.Min(c.R, 1), math.Min(c.G, 1), math.Min(c.B, 1)}
+}
+
+// Reinhard maps luminance L to L/(1+L), keeping the hue.
+type Reinhard struct{}
+
+func (Reinhard) Map(c RGB) RGB {
+	l := c.Luminance()
+	if l <= 0 {
+		return Black
+	}
+	return c.MulScalar(1 / (1 + l))
+}
+
+// ExtendedReinhard is Reinhard with White being the smallest luminance that
+// maps to pure white.
+type ExtendedReinhard struct {
+	White float64
+}
+
+func (t ExtendedReinhard) Map(c RGB) RGB {
+	l := c.Luminance()
+	if l <= 0 {
+		return Black
+	}
+	mapped := l * (1 + l/(t.White*t.White)) / (1 + l)
+	return c.MulScalar(mapped / l)
+}
+
+// ACES is Krzysztof Narkowicz's fit of the ACES filmic curve.
+type ACES struct{}
+
+func (ACES) Map(c RGB) RGB {
+	return RGB{aces(c.R), aces(c.G), aces(c.B)}
+}
+
+func aces(x float64) float64 {
+	const a, b, cc, d, e = 2.51, 0.03, 2.43, 0.59, 0.14
+	return (x * (a*x + b)) / (x*(cc*x+d) + e)
+}
+
+// ParseToneMapper returns the operator with the given name. white is only
+// used by the extended Reinhard operator.
+func ParseToneMapper(name string, white float64) (ToneMapper, error) {
+	switch name {
+	case "clamp":
+		return Clamp{}, nil
+	case "reinhard":
+		return Reinhard{}, nil
+	case "reinhard-extended":
+		if white <= 0 {
+			return nil, fmt.Errorf("white point must be positive")
+		}
+		return ExtendedReinhard{White: white}, nil
+	case "aces":
+		return ACES{}, nil
+	}
+	return nil, fmt.Errorf("unknown tone mapping operator %q", name)
+}
+
+// ToneMapping turns linear HDR pixels into displayable 8-bit ones. The zero
+// value clamps without exposure change and encodes with the sRGB curve.
+type ToneMapping struct {
+	// Exposure scales the image by 2^Exposure before tone mapping.
+	Exposure float64
+	// Operator defaults to Clamp.
+	Operator ToneMapper
+	// White is the white point for operators that take one. Apply ignores
+	// it; it lets a caller swapping Operator keep the configured value.
+	White float64
+	// Linear skips the sRGB transfer function.
+	Linear bool
+}
+
+func (t *ToneMapping) Apply(c RGB) Color {
+	if t.Exposure != 0 {
+		c = c.MulScalar(math.Exp2(t.Exposure))
+	}
+	if t.Operator != nil {
+		c = t.Operator.Map(c)
+	} else {
+		c = Clamp{}.Map(c)
+	}
+	if !t.Linear {
+		c = RGB{LinearToSRGB(c.R), LinearToSRGB(c.G), LinearToSRGB(c.B)}
+	}
+	return c.Color()
+}
+
+// ToneMap converts the image to 8 bits per channel with t.
+func (img *HDRImage) ToneMap(t ToneMapping) *image.RGBA {
+	rgba := image.NewRGBA(img.Bounds())
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			rgba.SetRGBA(x, y, t.Apply(img.RGBAt(x, y)))
+		}
+	}
+	return rgba
+}
+
+// LinearToSRGB applies the sRGB transfer function to a channel in [0, 1].
+func LinearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// SRGBToLinear inverts LinearToSRGB.
+func SRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
@@ -0,0 +1,100 @@
+// Package render ray traces scenes of spheres, planes and triangle meshes
+// lit by point, directional, spot and area lights, with a Whitted-style
+// tracer and a Monte Carlo path tracer as integrators.
+// Material for ray tracing got from https://gabrielgambetta.com/computer-graphics-from-scratch/
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"raytracing/vector3"
+)
+
+type Vec3 = vector3.Vector3
+
+// Color is the 8-bit colour images are written with.
+type Color = color.RGBA
+
+var Epsilon float64 = 0.001
+
+// Infinity is the tMax of rays without an end point.
+const Infinity = math.MaxFloat64
+
+func ReflectRay(ray Vec3, normal Vec3) Vec3 {
+	//in physics reflect = l - 2*n*dot(n,l)
+	//due to negate ligth vector
+	reflect := normal.Reflect(ray.Negate())
+	return reflect
+}
+
+// TraceRay returns the colour seen along a ray. A degenerate ray, one
+// without a finite non-zero direction, sees nothing and returns black.
+func TraceRay(startPoint Vec3, direction Vec3, scene *Scene, recursionDepth int8, tMin float64, tMax float64) RGB {
+	if !ValidRay(startPoint, direction) {
+		return Black
+	}
+
+	hit, ok := scene.intersect(startPoint, direction, tMin, tMax)
+	if !ok {
+		return scene.Background
+	}
+	return hit.Shape.Material().Shade(scene, &hit, recursionDepth)
+}
+
+// intersect finds the closest surface along a ray.
+func (s *Scene) intersect(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (Hit, bool) {
+	closestShape, closestT := s.accelerator().Closest(startPoint, direction, tMin, tMax)
+	if closestShape == nil {
+		return Hit{}, false
+	}
+	// P = O + tD
+	pointIntersect := vector3.Add(startPoint, direction.MulScalar(closestT))
+	normal := closestShape.NormalAt(pointIntersect)
+	// Light the side of the surface the ray came from.
+	entering := vector3.Dot(normal, direction) < 0
+	if !entering {
+		normal = normal.Negate()
+	}
+	return Hit{
+		Shape:     closestShape,
+		Point:     pointIntersect,
+		Normal:    normal,
+		Direction: direction,
+		Entering:  entering,
+	}, true
+}
+
+// ValidRay reports whether a ray has a finite origin and a finite non-zero
+// direction.
+func ValidRay(startPoint Vec3, direction Vec3) bool {
+	return finiteVec(startPoint) && finiteVec(direction) && direction.Length() != 0
+}
+
+// RefractRay bends the unit direction by Snell's law through a surface with
+// unit normal facing against it. eta is the ratio of the refractive index
+// on the incoming side to the one on the outgoing side. It reports false on
+// total internal reflection.
+func RefractRay(direction Vec3, normal Vec3, eta float64) (Vec3, bool) {
+	cosIncident := -vector3.Dot(direction, normal)
+	k := 1 - eta*eta*(1-cosIncident*cosIncident)
+	if k < 0 {
+		return Vec3{}, false
+	}
+	return vector3.Add(direction.MulScalar(eta), normal.MulScalar(eta*cosIncident-math.Sqrt(k))), true
+}
+
+// Fresnel returns the share of unpolarized light a dielectric surface
+// reflects, from the exact Fresnel equations. It is 1 under total internal
+// reflection.
+func Fresnel(cosIncident float64, eta float64) float64 {
+	cosIncident = math.Min(1, math.Max(0, cosIncident))
+	sinTransmitted := eta * math.Sqrt(1-cosIncident*cosIncident)
+	if sinTransmitted >= 1 {
+		return 1
+	}
+	cosTransmitted := math.Sqrt(1 - sinTransmitted*sinTransmitted)
+	rs := (eta*cosIncident - cosTransmitted) / (eta*cosIncident + cosTransmitted)
+	rp := (cosIncident - eta*cosTransmitted) / (cosIncident + eta*cosTransmitted)
+	return (rs*rs + rp*rp) / 2
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFresnel(t *testing.T) {
+	critical := math.Asin(1 / 1.5)
+	tests := []struct {
+		name  string
+		angle float64
+		eta   float64
+		want  float64
+	}{
+		{"normal incidence into glass", 0, 1 / 1.5, 0.04},
+		{"normal incidence out of glass", 0, 1.5, 0.04},
+		{"grazing incidence", math.Pi / 2, 1 / 1.5, 1},
+		{"past the critical angle", critical + 0.1, 1.5, 1},
+		{"no interface", math.Pi / 3, 1, 0},
+	}
+	for _, test := range tests {
+		if got := Fresnel(math.Cos(test.angle), test.eta); !near(got, test.want) {
+			t.Errorf("%s: Fresnel(cos %v, %v) = %v, want %v", test.name, test.angle, test.eta, got, test.want)
+		}
+	}
+}
+
+func TestRefractRay(t *testing.T) {
+	normal := Vec3{Y: 1}
+	critical := math.Asin(1 / 1.5)
+	tests := []struct {
+		name      string
+		angle     float64
+		eta       float64
+		refracted bool
+	}{
+		{"normal incidence", 0, 1 / 1.5, true},
+		{"into glass", math.Pi / 4, 1 / 1.5, true},
+		{"grazing into glass", math.Pi/2 - 1e-6, 1 / 1.5, true},
+		{"out of glass", critical - 0.1, 1.5, true},
+		{"total internal reflection", critical + 0.1, 1.5, false},
+		{"no interface", math.Pi / 3, 1, true},
+	}
+	for _, test := range tests {
+		sinI, cosI := math.Sincos(test.angle)
+		direction := Vec3{X: sinI, Y: -cosI}
+		got, ok := RefractRay(direction, normal, test.eta)
+		if ok != test.refracted {
+			t.Errorf("%s: RefractRay() ok = %v, want %v", test.name, ok, test.refracted)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if l := got.Length(); !near(l, 1) {
+			t.Errorf("%s: RefractRay() = %v with length %v, want a unit vector", test.name, got, l)
+		}
+		if got.Y >= 0 {
+			t.Errorf("%s: RefractRay() = %v, want it through the surface", test.name, got)
+		}
+		// Snell's law: the tangential component scales by eta.
+		if sinT := got.X; !near(sinT, test.eta*sinI) {
+			t.Errorf("%s: sin of refracted angle = %v, want %v", test.name, sinT, test.eta*sinI)
+		}
+	}
+}
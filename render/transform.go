@@ -0,0 +1,87 @@
+package render
+
+import (
+	"math"
+
+	"raytracing/vector3"
+)
+
+// Transform is an affine transform made of a scale, a rotation and a
+// translation, applied in that order.
+type Transform struct {
+	// linear is the 3x3 scale and rotation part, offset the translation.
+	linear [3][3]float64
+	offset Vec3
+	// normal is the inverse transpose of linear, used for normals.
+	normal [3][3]float64
+}
+
+func Identity() Transform {
+	return NewTransform(Vec3{}, Vec3{}, Vec3{X: 1, Y: 1, Z: 1})
+}
+
+// NewTransform scales by scale, rotates by rotate degrees about the X, Y
+// and Z axes in that order, then moves by translate. Scale components must
+// not be zero.
+func NewTransform(translate Vec3, rotate Vec3, scale Vec3) Transform {
+	s := [3][3]float64{{scale.X, 0, 0}, {0, scale.Y, 0}, {0, 0, scale.Z}}
+	rx := rotation(0, rotate.X)
+	ry := rotation(1, rotate.Y)
+	rz := rotation(2, rotate.Z)
+	r := mul3(rz, mul3(ry, rx))
+
+	// (R*S)^-T = R^-T * S^-T = R * S^-1 because R is orthonormal.
+	inverseScale := [3][3]float64{{1 / scale.X, 0, 0}, {0, 1 / scale.Y, 0}, {0, 0, 1 / scale.Z}}
+	return Transform{
+		linear: mul3(r, s),
+		offset: translate,
+		normal: mul3(r, inverseScale),
+	}
+}
+
+// rotation returns the matrix rotating by degrees about the given axis.
+func rotation(axis int, degrees float64) [3][3]float64 {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	switch axis {
+	case 0:
+		return [3][3]float64{{1, 0, 0}, {0, cos, -sin}, {0, sin, cos}}
+	case 1:
+		return [3][3]float64{{cos, 0, sin}, {0, 1, 0}, {-sin, 0, cos}}
+	default:
+		return [3][3]float64{{cos, -sin, 0}, {sin, cos, 0}, {0, 0, 1}}
+	}
+}
+
+func mul3(a [3][3]float64, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+func apply3(m *[3][3]float64, v Vec3) Vec3 {
+	return Vec3{
+		X: m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z,
+		Y: m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z,
+		Z: m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+func (t *Transform) Point(p Vec3) Vec3 {
+	return vector3.Add(apply3(&t.linear, p), t.offset)
+}
+
+func (t *Transform) Vector(v Vec3) Vec3 {
+	return apply3(&t.linear, v)
+}
+
+// Normal transforms a surface normal and returns it at unit length.
+func (t *Transform) Normal(n Vec3) Vec3 {
+	normal := apply3(&t.normal, n)
+	return normal.Normalize()
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"math"
+
+	"raytracing/vector3"
+)
+
+// UV is a texture coordinate.
+type UV struct {
+	U, V float64
+}
+
+// Triangle is a single triangle with vertices V0, V1, V2 in counter-clockwise
+// order around the front face. When HasNormals is set, the vertex normals
+// N0, N1, N2 are interpolated for smooth shading. UV0, UV1, UV2 are only
+// meaningful when HasUVs is set.
+type Triangle struct {
+	V0, V1, V2    Vec3
+	N0, N1, N2    Vec3
+	UV0, UV1, UV2 UV
+	HasNormals    bool
+	HasUVs        bool
+	Surface       Material
+}
+
+// Intersect uses the Möller–Trumbore algorithm.
+func (tr *Triangle) Intersect(startPoint Vec3, direction Vec3, tMin float64, tMax float64) (float64, bool) {
+	edge1 := vector3.Sub(tr.V1, tr.V0)
+	edge2 := vector3.Sub(tr.V2, tr.V0)
+	p := vector3.Cross(direction, edge2)
+	det := vector3.Dot(edge1, p)
+	// The ray is parallel to the triangle plane.
+	if math.Abs(det) < 1e-12 {
+		return 0, false
+	}
+	invDet := 1 / det
+	s := vector3.Sub(startPoint, tr.V0)
+	u := vector3.Dot(s, p) * invDet
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := vector3.Cross(s, edge1)
+	v := vector3.Dot(direction, q) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	t := vector3.Dot(edge2, q) * invDet
+	if t < tMin || t > tMax {
+		return 0, false
+	}
+	return t, true
+}
+
+// FaceNormal returns the geometric normal given by the winding order.
+func (tr *Triangle) FaceNormal() Vec3 {
+	normal := vector3.Cross(vector3.Sub(tr.V1, tr.V0), vector3.Sub(tr.V2, tr.V0))
+	return normal.Normalize()
+}
+
+func (tr *Triangle) NormalAt(point Vec3) Vec3 {
+	if !tr.HasNormals {
+		return tr.FaceNormal()
+	}
+	w0, w1, w2 := tr.Barycentric(point)
+	normal := vector3.Add(tr.N0.MulScalar(w0), vector3.Add(tr.N1.MulScalar(w1), tr.N2.MulScalar(w2)))
+	return normal.Normalize()
+}
+
+// UVAt interpolates the vertex texture coordinates at a point on the triangle.
+func (tr *Triangle) UVAt(point Vec3) UV {
+	if !tr.HasUVs {
+		return UV{}
+	}
+	w0, w1, w2 := tr.Barycentric(point)
+	return UV{
+		U: w0*tr.UV0.U + w1*tr.UV1.U + w2*tr.UV2.U,
+		V: w0*tr.UV0.V + w1*tr.UV1.V + w2*tr.UV2.V,
+	}
+}
+
+// Barycentric returns the weights of V0, V1 and V2 for a point in the
+// triangle's plane.
+func (tr *Triangle) Barycentric(point Vec3) (float64, float64, float64) {
+	e1 := vector3.Sub(tr.V1, tr.V0)
+	e2 := vector3.Sub(tr.V2, tr.V0)
+	ep := vector3.Sub(point, tr.V0)
+	d11 := vector3.Dot(e1, e1)
+	d12 := vector3.Dot(e1, e2)
+	d22 := vector3.Dot(e2, e2)
+	dp1 := vector3.Dot(ep, e1)
+	dp2 := vector3.Dot(ep, e2)
+	denominator := d11*d22 - d12*d12
+	if denominator == 0 {
+		return 1, 0, 0
+	}
+	w1 := (d22*dp1 - d12*dp2) / denominator
+	w2 := (d11*dp2 - d12*dp1) / denominator
+	return 1 - w1 - w2, w1, w2
+}
+
+func (tr *Triangle) Material() Material {
+	return tr.Surface
+}
@@ -0,0 +1,174 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+
+	"raytracing/vector3"
+)
+
+// Validate checks the scene up front so that broken objects are reported
+// instead of producing garbage or NaNs halfway through a render. It returns
+// all problems found, joined, each as a *ValidationError.
+func (s *Scene) Validate() error {
+	var errs []error
+	report := func(object string, msg string) {
+		errs = append(errs, &ValidationError{Object: object, Msg: msg})
+	}
+
+	if err := s.Camera.Validate(); err != nil {
+		report("camera", err.Error())
+	} else if !finiteVec(s.Camera.Position) || !finiteVec(s.Camera.LookAt) || !finiteVec(s.Camera.Up) {
+		report("camera", "position, look-at and up must be finite")
+	}
+	if !validColor(s.Background) {
+		report("background", "colour must be finite and non-negative")
+	}
+
+	// Shared materials are checked once. Only pointers are keyed on: value
+	// materials may hold slices or maps, which would panic as map keys.
+	checked := make(map[Material]bool)
+	for i, shape := range s.Shapes {
+		object := fmt.Sprintf("shapes[%d]", i)
+		if shape == nil {
+			report(object, "nil shape")
+			continue
+		}
+		if v, ok := shape.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				report(object, err.Error())
+			}
+		}
+		material := shape.Material()
+		if material == nil {
+			report(object, "no material")
+			continue
+		}
+		if reflect.TypeOf(material).Kind() == reflect.Pointer {
+			if checked[material] {
+				continue
+			}
+			checked[material] = true
+		}
+		if v, ok := material.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				report(object+" material", err.Error())
+			}
+		}
+	}
+
+	for i := range s.Lights {
+		if err := s.Lights[i].Validate(); err != nil {
+			report(fmt.Sprintf("lights[%d]", i), err.Error())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Sphere) Validate() error {
+	if !finiteVec(s.Center) || !finite(s.Radius) {
+		return errors.New("sphere center and radius must be finite")
+	}
+	if s.Radius <= 0 {
+		return errors.New("sphere radius must be positive")
+	}
+	return nil
+}
+
+func (p *Plane) Validate() error {
+	if !finiteVec(p.Point) || !finiteVec(p.Normal) {
+		return errors.New("plane point and normal must be finite")
+	}
+	if p.Normal.Length() == 0 {
+		return errors.New("plane normal must not be zero")
+	}
+	return nil
+}
+
+func (tr *Triangle) Validate() error {
+	if !finiteVec(tr.V0) || !finiteVec(tr.V1) || !finiteVec(tr.V2) {
+		return errors.New("triangle vertices must be finite")
+	}
+	if normal := tr.FaceNormal(); normal.Length() == 0 {
+		return errors.New("triangle is degenerate")
+	}
+	if tr.HasNormals && (!finiteVec(tr.N0) || !finiteVec(tr.N1) || !finiteVec(tr.N2)) {
+		return errors.New("triangle vertex normals must be finite")
+	}
+	return nil
+}
+
+func (m *Phong) Validate() error {
+	if !validColor(m.Color) {
+		return errors.New("colour must be finite and non-negative")
+	}
+	if !finite(m.Specular) || (m.Specular < 0 && m.Specular != -1) {
+		return errors.New("specular exponent must be non-negative, or -1")
+	}
+	if !(m.Reflective >= 0 && m.Reflective <= 1) {
+		return errors.New("reflective must be between 0 and 1")
+	}
+	if !(m.Transparency >= 0 && m.Transparency <= 1) {
+		return errors.New("transparency must be between 0 and 1")
+	}
+	if !finite(m.RefractiveIndex) || m.RefractiveIndex < 0 {
+		return errors.New("refractive index must be non-negative")
+	}
+	return nil
+}
+
+func (light *Light) Validate() error {
+	if !finite(light.Intensity) || light.Intensity < 0 {
+		return errors.New("intensity must be finite and non-negative")
+	}
+	if !validColor(light.Color) {
+		return errors.New("colour must be finite and non-negative")
+	}
+	if a := light.Attenuation; !finite(a.Constant, a.Linear, a.Quadratic) || a.Constant < 0 || a.Linear < 0 || a.Quadratic < 0 {
+		return errors.New("attenuation coefficients must be finite and non-negative")
+	}
+	if light.Samples < 0 {
+		return errors.New("samples must not be negative")
+	}
+	switch light.Type {
+	case Ambient:
+	case Point:
+		if !finiteVec(light.Position) {
+			return errors.New("position must be finite")
+		}
+	case Spot:
+		if !finiteVec(light.Position) || !finiteVec(light.Direction) || light.Direction.Length() == 0 {
+			return errors.New("position and direction must be finite, direction non-zero")
+		}
+		if !finite(light.InnerAngle, light.OuterAngle) || light.InnerAngle < 0 || light.OuterAngle > math.Pi ||
+			light.InnerAngle > light.OuterAngle {
+			return errors.New("cone angles must satisfy 0 <= inner <= outer <= 180°")
+		}
+	case Directional:
+		if !finiteVec(light.Direction) || light.Direction.Length() == 0 {
+			return errors.New("direction must be finite and non-zero")
+		}
+	case Rectangle:
+		if !finiteVec(light.Position) || !finiteVec(light.Edge1) || !finiteVec(light.Edge2) {
+			return errors.New("position and edges must be finite")
+		}
+		if front := vector3.Cross(light.Edge1, light.Edge2); front.Length() == 0 {
+			return errors.New("rectangle is degenerate")
+		}
+	case Disk, Spherical:
+		if !finiteVec(light.Position) {
+			return errors.New("position must be finite")
+		}
+		if light.Type == Disk && (!finiteVec(light.Direction) || light.Direction.Length() == 0) {
+			return errors.New("direction must be finite and non-zero")
+		}
+		if !finite(light.Radius) || light.Radius <= 0 {
+			return errors.New("radius must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown light type %d", light.Type)
+	}
+	return nil
+}
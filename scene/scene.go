@@ -0,0 +1,1065 @@
+// Package scene reads JSON scene descriptions.
+package scene
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"raytracing/obj"
+	"raytracing/render"
+)
+
+// Version is the only scene file format version understood by Load.
+const Version = 1
+
+// Error describes a problem in a scene file. Line is 0 when the
+// position of the offending value is unknown.
+type Error struct {
+	File  string
+	Line  int
+	Field string
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, ":%d", e.Line)
+	}
+	if e.Field != "" {
+		sb.WriteString(": ")
+		sb.WriteString(e.Field)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(e.Msg)
+	return sb.String()
+}
+
+type sceneFile struct {
+	Version    int                        `json:"version"`
+	Render     renderSection              `json:"render"`
+	Camera     cameraSection              `json:"camera"`
+	Background []float64                  `json:"background"`
+	Materials  map[string]materialSection `json:"materials"`
+	Spheres    []sphereSection            `json:"spheres"`
+	Planes     []planeSection             `json:"planes"`
+	Triangles  []triangleSection          `json:"triangles"`
+	Meshes     []meshSection              `json:"meshes"`
+	Lights     []lightSection             `json:"lights"`
+}
+
+type renderSection struct {
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	RecursionDepth int     `json:"recursion_depth"`
+	ToneMap        string  `json:"tone_map"`
+	White          float64 `json:"white"`
+	Exposure       float64 `json:"exposure"`
+	Linear         bool    `json:"linear"`
+	Samples        int     `json:"samples"`
+	SamplePattern  string  `json:"sample_pattern"`
+	Integrator     string  `json:"integrator"`
+}
+
+type cameraSection struct {
+	Position []float64 `json:"position"`
+	LookAt   []float64 `json:"look_at"`
+	Up       []float64 `json:"up"`
+	FOV      float64   `json:"fov"`
+}
+
+// materialSection describes a material, either in the top-level materials
+// table or inline in a shape.
+type materialSection struct {
+	Type         string    `json:"type"`
+	Color        []float64 `json:"color"`
+	Specular     *float64  `json:"specular"`
+	Reflective   float64   `json:"reflective"`
+	Transparency float64   `json:"transparency"`
+	IOR          *float64  `json:"ior"`
+	// Texture multiplies the colour, which then defaults to white.
+	Texture *textureSection `json:"texture"`
+	// The luminance of these textures scales the highlights and
+	// reflective.
+	SpecularTexture   *textureSection `json:"specular_texture"`
+	ReflectiveTexture *textureSection `json:"reflective_texture"`
+}
+
+func (m *materialSection) isSet() bool {
+	return m.Type != "" || m.Color != nil || m.Specular != nil || m.Reflective != 0 || m.Transparency != 0 || m.IOR != nil ||
+		m.Texture != nil || m.SpecularTexture != nil || m.ReflectiveTexture != nil
+}
+
+// textureSection is either an image file or one of the procedural
+// textures: checker, stripes, gradient, noise, turbulence, marble or wood.
+type textureSection struct {
+	Type string `json:"type"`
+	// Image textures.
+	File   string `json:"file"`
+	Wrap   string `json:"wrap"`
+	Filter string `json:"filter"`
+	// Procedural textures blend between two colours.
+	Colors [][]float64 `json:"colors"`
+	// Size is the cell size of checker and the stripe width of stripes.
+	Size float64   `json:"size"`
+	Axis []float64 `json:"axis"`
+	// Start and End are the ends of a gradient.
+	Start []float64 `json:"start"`
+	End   []float64 `json:"end"`
+	// Noise textures: Scale is the frequency of the noise, Seed picks
+	// the noise, Octaves and Strength shape the turbulence. Center is
+	// the axis of the rings of wood.
+	Scale    float64   `json:"scale"`
+	Seed     uint64    `json:"seed"`
+	Octaves  int       `json:"octaves"`
+	Strength *float64  `json:"strength"`
+	Center   []float64 `json:"center"`
+}
+
+// surfaceSection is embedded by every shape: either the name of a shared
+// material or the properties of an inline one.
+type surfaceSection struct {
+	Material string `json:"material"`
+	materialSection
+}
+
+type sphereSection struct {
+	Center []float64 `json:"center"`
+	Radius float64   `json:"radius"`
+	surfaceSection
+}
+
+type triangleSection struct {
+	Vertices [][]float64 `json:"vertices"`
+	Normals  [][]float64 `json:"normals"`
+	UVs      [][]float64 `json:"uvs"`
+	surfaceSection
+}
+
+type meshSection struct {
+	File      string    `json:"file"`
+	Translate []float64 `json:"translate"`
+	Rotate    []float64 `json:"rotate"`
+	Scale     []float64 `json:"scale"`
+	surfaceSection
+}
+
+type planeSection struct {
+	Point  []float64 `json:"point"`
+	Normal []float64 `json:"normal"`
+	surfaceSection
+}
+
+type lightSection struct {
+	Type      string    `json:"type"`
+	Position  []float64 `json:"position"`
+	Direction []float64 `json:"direction"`
+	Color     []float64 `json:"color"`
+	Intensity float64   `json:"intensity"`
+	Edge1     []float64 `json:"edge1"`
+	Edge2     []float64 `json:"edge2"`
+	Radius    float64   `json:"radius"`
+	Samples   int       `json:"samples"`
+	// Cone half-angles of spot lights, in degrees.
+	InnerAngle float64 `json:"inner_angle"`
+	OuterAngle float64 `json:"outer_angle"`
+	// Attenuation is "none", "inverse-square" or the [constant, linear,
+	// quadratic] coefficients.
+	Attenuation any `json:"attenuation"`
+}
+
+// Load reads and validates a JSON scene description. The returned options
+// hold the render settings stored in the file.
+func Load(path string) (*render.Scene, render.Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, render.Options{}, err
+	}
+	return Parse(path, data)
+}
+
+// Parse decodes a scene from data. The name is only used in error messages.
+func Parse(name string, data []byte) (*render.Scene, render.Options, error) {
+	file := sceneFile{
+		Render: renderSection{
+			Width:          2048,
+			Height:         2048,
+			RecursionDepth: 3,
+			ToneMap:        "clamp",
+			White:          4,
+			Samples:        1,
+			SamplePattern:  "jittered",
+			Integrator:     "whitted",
+		},
+		Camera: cameraSection{
+			Position: []float64{0, 0, 0},
+			LookAt:   []float64{0, 0, 1},
+			Up:       []float64{0, 1, 0},
+			FOV:      90,
+		},
+		Background: []float64{125, 125, 125},
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, render.Options{}, decodeError(name, data, err)
+	}
+
+	p := parser{name: name, positions: indexPositions(data), data: data}
+	scene, err := p.build(&file)
+	if err != nil {
+		return nil, render.Options{}, err
+	}
+	options, err := p.options(&file.Render)
+	if err != nil {
+		return nil, render.Options{}, err
+	}
+	return scene, options, nil
+}
+
+func decodeError(name string, data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &Error{File: name, Line: lineAt(data, syntaxErr.Offset), Msg: syntaxErr.Error()}
+	case errors.As(err, &typeErr):
+		msg := fmt.Sprintf("cannot use %s as %s", typeErr.Value, typeErr.Type)
+		return &Error{File: name, Line: lineAt(data, typeErr.Offset), Field: bracketPath(typeErr.Field), Msg: msg}
+	}
+	msg := strings.TrimPrefix(err.Error(), "json: ")
+	// The decoder does not say where an unknown field is, so look for the
+	// first key of that name the scene format has no place for.
+	if quoted, ok := strings.CutPrefix(msg, "unknown field "); ok {
+		if field, err := strconv.Unquote(quoted); err == nil {
+			if path, offset, ok := unknownField(data, field); ok {
+				return &Error{File: name, Line: lineAt(data, offset), Field: path, Msg: "unknown field"}
+			}
+		}
+	}
+	return &Error{File: name, Msg: msg}
+}
+
+// bracketPath turns the dotted paths of encoding/json, e.g.
+// "spheres.0.radius", into the form used elsewhere: "spheres[0].radius".
+func bracketPath(path string) string {
+	var sb strings.Builder
+	for i, part := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			fmt.Fprintf(&sb, "[%s]", part)
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(part)
+	}
+	return sb.String()
+}
+
+// unknownField returns the path and offset of the first key named field,
+// in document order, that sceneFile does not define.
+func unknownField(data []byte, field string) (string, int64, bool) {
+	positions := indexPositions(data)
+	paths := make([]string, 0, len(positions))
+	for path := range positions {
+		if path == field || strings.HasSuffix(path, "."+field) {
+			paths = append(paths, path)
+		}
+	}
+	slices.SortFunc(paths, func(a, b string) int { return int(positions[a] - positions[b]) })
+	for _, path := range paths {
+		if !knownPath(reflect.TypeFor[sceneFile](), path) {
+			return path, positions[path], true
+		}
+	}
+	return "", 0, false
+}
+
+// knownPath reports whether path, as built by indexPositions, leads to a
+// field of t.
+func knownPath(t reflect.Type, path string) bool {
+	for path != "" {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if strings.HasPrefix(path, "[") {
+			end := strings.IndexByte(path, ']')
+			if end < 0 || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+				return t.Kind() == reflect.Interface
+			}
+			t, path = t.Elem(), path[end+1:]
+			continue
+		}
+		path = strings.TrimPrefix(path, ".")
+		key := path
+		if i := strings.IndexAny(path, ".["); i >= 0 {
+			key = path[:i]
+		}
+		path = path[len(key):]
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			f, ok := jsonField(t, key)
+			if !ok {
+				return false
+			}
+			t = f.Type
+		default:
+			// Free-form values such as attenuation accept anything.
+			return t.Kind() == reflect.Interface
+		}
+	}
+	return true
+}
+
+// jsonField finds the field with the given JSON name, looking into embedded
+// structs like encoding/json does.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if inner, ok := jsonField(f.Type, name); ok {
+				return inner, true
+			}
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == name || (tag == "" && strings.EqualFold(f.Name, name)) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+type parser struct {
+	name      string
+	data      []byte
+	positions map[string]int64
+	materials map[string]render.Material
+	// images caches decoded texture files by path.
+	images map[string]*render.HDRImage
+}
+
+func (p *parser) errorf(field string, format string, args ...any) error {
+	// Missing values have no position of their own; point at the closest
+	// enclosing one instead.
+	line := 0
+	for path := field; path != ""; path = parentPath(path) {
+		if offset, ok := p.positions[path]; ok {
+			line = lineAt(p.data, offset)
+			break
+		}
+	}
+	return &Error{File: p.name, Line: line, Field: field, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parentPath strips the last key or index from a path: "spheres[0].color"
+// becomes "spheres[0]", which becomes "spheres".
+func parentPath(path string) string {
+	i := strings.LastIndexAny(path, ".[")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+func (p *parser) build(file *sceneFile) (*render.Scene, error) {
+	if file.Version != Version {
+		return nil, p.errorf("version", "unsupported version %d, expected %d", file.Version, Version)
+	}
+	if file.Camera.FOV <= 0 || file.Camera.FOV >= 180 {
+		return nil, p.errorf("camera.fov", "must be between 0 and 180 degrees")
+	}
+
+	scene := &render.Scene{
+		Camera: render.Camera{FOV: file.Camera.FOV},
+	}
+	var err error
+	if scene.Camera.Position, err = p.vector("camera.position", file.Camera.Position); err != nil {
+		return nil, err
+	}
+	if scene.Camera.LookAt, err = p.vector("camera.look_at", file.Camera.LookAt); err != nil {
+		return nil, err
+	}
+	if scene.Camera.Up, err = p.vector("camera.up", file.Camera.Up); err != nil {
+		return nil, err
+	}
+	if err := scene.Camera.Validate(); err != nil {
+		return nil, p.errorf("camera", "%s", strings.TrimPrefix(err.Error(), "camera: "))
+	}
+	if scene.Background, err = p.color("background", file.Background); err != nil {
+		return nil, err
+	}
+
+	// Sorted so the first invalid material reported is deterministic.
+	p.materials = make(map[string]render.Material, len(file.Materials))
+	for _, name := range slices.Sorted(maps.Keys(file.Materials)) {
+		m := file.Materials[name]
+		if p.materials[name], err = p.material("materials."+name, &m); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, s := range file.Spheres {
+		field := fmt.Sprintf("spheres[%d]", i)
+		sphere := &render.Sphere{Radius: s.Radius}
+		if sphere.Center, err = p.vector(field+".center", s.Center); err != nil {
+			return nil, err
+		}
+		if s.Radius <= 0 {
+			return nil, p.errorf(field+".radius", "must be positive")
+		}
+		if sphere.Surface, err = p.surface(field, &s.surfaceSection); err != nil {
+			return nil, err
+		}
+		scene.Shapes = append(scene.Shapes, sphere)
+	}
+
+	for i, pl := range file.Planes {
+		field := fmt.Sprintf("planes[%d]", i)
+		plane := &render.Plane{}
+		if plane.Point, err = p.vector(field+".point", pl.Point); err != nil {
+			return nil, err
+		}
+		if plane.Normal, err = p.vector(field+".normal", pl.Normal); err != nil {
+			return nil, err
+		}
+		if plane.Normal.Length() == 0 {
+			return nil, p.errorf(field+".normal", "must not be zero")
+		}
+		if plane.Surface, err = p.surface(field, &pl.surfaceSection); err != nil {
+			return nil, err
+		}
+		scene.Shapes = append(scene.Shapes, plane)
+	}
+
+	for i, t := range file.Triangles {
+		field := fmt.Sprintf("triangles[%d]", i)
+		triangle, err := p.triangle(field, &t)
+		if err != nil {
+			return nil, err
+		}
+		scene.Shapes = append(scene.Shapes, triangle)
+	}
+
+	for i, m := range file.Meshes {
+		field := fmt.Sprintf("meshes[%d]", i)
+		triangles, err := p.mesh(field, &m)
+		if err != nil {
+			return nil, err
+		}
+		for _, triangle := range triangles {
+			scene.Shapes = append(scene.Shapes, triangle)
+		}
+	}
+
+	for i := range file.Lights {
+		light, err := p.light(fmt.Sprintf("lights[%d]", i), &file.Lights[i])
+		if err != nil {
+			return nil, err
+		}
+		scene.Lights = append(scene.Lights, light)
+	}
+	return scene, nil
+}
+
+func (p *parser) light(field string, l *lightSection) (render.Light, error) {
+	light := render.Light{Color: render.White, Intensity: l.Intensity, Samples: l.Samples}
+	var err error
+	if l.Color != nil {
+		if light.Color, err = p.color(field+".color", l.Color); err != nil {
+			return light, err
+		}
+	}
+	area, spot := false, false
+	switch l.Type {
+	case "point":
+		light.Type = render.Point
+		if light.Position, err = p.vector(field+".position", l.Position); err != nil {
+			return light, err
+		}
+	case "spot":
+		light.Type, spot = render.Spot, true
+		if light.Position, err = p.vector(field+".position", l.Position); err != nil {
+			return light, err
+		}
+		if light.Direction, err = p.vector(field+".direction", l.Direction); err != nil {
+			return light, err
+		}
+		if light.Direction.Length() == 0 {
+			return light, p.errorf(field+".direction", "must not be zero")
+		}
+		if l.OuterAngle <= 0 || l.OuterAngle > 180 {
+			return light, p.errorf(field+".outer_angle", "must be between 0 and 180 degrees")
+		}
+		if l.InnerAngle < 0 || l.InnerAngle > l.OuterAngle {
+			return light, p.errorf(field+".inner_angle", "must be between 0 and outer_angle")
+		}
+		light.InnerAngle = l.InnerAngle * math.Pi / 180
+		light.OuterAngle = l.OuterAngle * math.Pi / 180
+	case "directional":
+		light.Type = render.Directional
+		if light.Direction, err = p.vector(field+".direction", l.Direction); err != nil {
+			return light, err
+		}
+		if light.Direction.Length() == 0 {
+			return light, p.errorf(field+".direction", "must not be zero")
+		}
+	case "ambient":
+		light.Type = render.Ambient
+		if l.Position != nil {
+			return light, p.errorf(field+".position", "ambient lights have no position")
+		}
+	case "rectangle":
+		light.Type, area = render.Rectangle, true
+		if light.Position, err = p.vector(field+".position", l.Position); err != nil {
+			return light, err
+		}
+		if light.Edge1, err = p.vector(field+".edge1", l.Edge1); err != nil {
+			return light, err
+		}
+		if light.Edge2, err = p.vector(field+".edge2", l.Edge2); err != nil {
+			return light, err
+		}
+		if front := light.Edge1.Cross(light.Edge2); front.Length() == 0 {
+			return light, p.errorf(field+".edge2", "must not be parallel to edge1")
+		}
+	case "disk", "sphere":
+		light.Type, area = render.Spherical, true
+		if light.Position, err = p.vector(field+".position", l.Position); err != nil {
+			return light, err
+		}
+		if l.Type == "disk" {
+			light.Type = render.Disk
+			if light.Direction, err = p.vector(field+".direction", l.Direction); err != nil {
+				return light, err
+			}
+			if light.Direction.Length() == 0 {
+				return light, p.errorf(field+".direction", "must not be zero")
+			}
+		}
+		if l.Radius <= 0 {
+			return light, p.errorf(field+".radius", "must be positive")
+		}
+		light.Radius = l.Radius
+	case "":
+		return light, p.errorf(field, "missing light type")
+	default:
+		return light, p.errorf(field+".type", "unknown light type %q", l.Type)
+	}
+	if l.Intensity < 0 {
+		return light, p.errorf(field+".intensity", "must be non-negative")
+	}
+	if l.Samples < 0 {
+		return light, p.errorf(field+".samples", "must not be negative")
+	}
+	if !area && l.Samples != 0 {
+		return light, p.errorf(field+".samples", "only area lights are sampled")
+	}
+	if !spot && (l.InnerAngle != 0 || l.OuterAngle != 0) {
+		return light, p.errorf(field, "only spot lights have cone angles")
+	}
+	if l.Attenuation != nil {
+		if light.Type != render.Point && light.Type != render.Spot {
+			return light, p.errorf(field+".attenuation", "only point and spot lights are attenuated")
+		}
+		if light.Attenuation, err = p.attenuation(field+".attenuation", l.Attenuation); err != nil {
+			return light, err
+		}
+	}
+	return light, nil
+}
+
+func (p *parser) attenuation(field string, value any) (render.Attenuation, error) {
+	switch v := value.(type) {
+	case string:
+		switch v {
+		case "none":
+			return render.Attenuation{}, nil
+		case "inverse-square":
+			return render.InverseSquare, nil
+		}
+		return render.Attenuation{}, p.errorf(field, "unknown attenuation %q", v)
+	case []any:
+		if len(v) != 3 {
+			return render.Attenuation{}, p.errorf(field, "expected 3 coefficients, got %d", len(v))
+		}
+		var k [3]float64
+		for i := range v {
+			f, ok := v[i].(float64)
+			if !ok || f < 0 {
+				return render.Attenuation{}, p.errorf(field, "coefficients must be non-negative numbers")
+			}
+			k[i] = f
+		}
+		if k == [3]float64{} {
+			return render.Attenuation{}, p.errorf(field, "coefficients must not all be zero")
+		}
+		return render.Attenuation{Constant: k[0], Linear: k[1], Quadratic: k[2]}, nil
+	}
+	return render.Attenuation{}, p.errorf(field, `expected "none", "inverse-square" or [constant, linear, quadratic]`)
+}
+
+func (p *parser) triangle(field string, t *triangleSection) (*render.Triangle, error) {
+	triangle := &render.Triangle{}
+	if len(t.Vertices) != 3 {
+		return nil, p.errorf(field+".vertices", "expected 3 vertices, got %d", len(t.Vertices))
+	}
+	vertices := [3]*render.Vec3{&triangle.V0, &triangle.V1, &triangle.V2}
+	var err error
+	for i, v := range t.Vertices {
+		if *vertices[i], err = p.vector(fmt.Sprintf("%s.vertices[%d]", field, i), v); err != nil {
+			return nil, err
+		}
+	}
+	if normal := triangle.FaceNormal(); normal.Length() == 0 {
+		return nil, p.errorf(field+".vertices", "triangle is degenerate")
+	}
+
+	if t.Normals != nil {
+		if len(t.Normals) != 3 {
+			return nil, p.errorf(field+".normals", "expected 3 normals, got %d", len(t.Normals))
+		}
+		normals := [3]*render.Vec3{&triangle.N0, &triangle.N1, &triangle.N2}
+		for i, n := range t.Normals {
+			nField := fmt.Sprintf("%s.normals[%d]", field, i)
+			if *normals[i], err = p.vector(nField, n); err != nil {
+				return nil, err
+			}
+			if normals[i].Length() == 0 {
+				return nil, p.errorf(nField, "must not be zero")
+			}
+		}
+		triangle.HasNormals = true
+	}
+
+	if t.UVs != nil {
+		if len(t.UVs) != 3 {
+			return nil, p.errorf(field+".uvs", "expected 3 texture coordinates, got %d", len(t.UVs))
+		}
+		uvs := [3]*render.UV{&triangle.UV0, &triangle.UV1, &triangle.UV2}
+		for i, uv := range t.UVs {
+			if len(uv) != 2 {
+				return nil, p.errorf(fmt.Sprintf("%s.uvs[%d]", field, i), "expected 2 components, got %d", len(uv))
+			}
+			*uvs[i] = render.UV{U: uv[0], V: uv[1]}
+		}
+		triangle.HasUVs = true
+	}
+
+	if triangle.Surface, err = p.surface(field, &t.surfaceSection); err != nil {
+		return nil, err
+	}
+	return triangle, nil
+}
+
+// mesh loads an OBJ file named relative to the scene file.
+func (p *parser) mesh(field string, m *meshSection) ([]*render.Triangle, error) {
+	if m.File == "" {
+		return nil, p.errorf(field+".file", "missing value")
+	}
+	path := p.resolve(m.File)
+
+	translate, rotate, scale := []float64{0, 0, 0}, []float64{0, 0, 0}, []float64{1, 1, 1}
+	if m.Translate != nil {
+		translate = m.Translate
+	}
+	if m.Rotate != nil {
+		rotate = m.Rotate
+	}
+	if m.Scale != nil {
+		scale = m.Scale
+	}
+	t, err := p.vector(field+".translate", translate)
+	if err != nil {
+		return nil, err
+	}
+	r, err := p.vector(field+".rotate", rotate)
+	if err != nil {
+		return nil, err
+	}
+	s, err := p.vector(field+".scale", scale)
+	if err != nil {
+		return nil, err
+	}
+	if s.X == 0 || s.Y == 0 || s.Z == 0 {
+		return nil, p.errorf(field+".scale", "components must not be zero")
+	}
+	surface, err := p.surface(field, &m.surfaceSection)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := obj.Load(path)
+	if err != nil {
+		return nil, p.errorf(field+".file", "%s", err)
+	}
+	triangles := model.Triangles(render.NewTransform(t, r, s), surface)
+	if len(triangles) == 0 {
+		return nil, p.errorf(field+".file", "%s has no faces", m.File)
+	}
+	return triangles, nil
+}
+
+// resolve makes paths in the scene relative to the scene file.
+func (p *parser) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(p.name), path)
+}
+
+func (p *parser) texture(field string, t *textureSection) (render.Texture, error) {
+	if t.Type == "" || t.Type == "image" {
+		return p.imageTexture(field, t)
+	}
+	if t.File != "" || t.Wrap != "" || t.Filter != "" {
+		return nil, p.errorf(field, "only image textures have a file, wrap or filter")
+	}
+
+	colors := [][]float64{{0, 0, 0}, {255, 255, 255}}
+	if t.Colors != nil {
+		if len(t.Colors) != 2 {
+			return nil, p.errorf(field+".colors", "expected 2 colours, got %d", len(t.Colors))
+		}
+		colors = t.Colors
+	}
+	a, err := p.color(field+".colors[0]", colors[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := p.color(field+".colors[1]", colors[1])
+	if err != nil {
+		return nil, err
+	}
+	size, scale := 1., 1.
+	if t.Size < 0 {
+		return nil, p.errorf(field+".size", "must be positive")
+	} else if t.Size > 0 {
+		size = t.Size
+	}
+	if t.Scale < 0 {
+		return nil, p.errorf(field+".scale", "must be positive")
+	} else if t.Scale > 0 {
+		scale = t.Scale
+	}
+	octaves := 6
+	if t.Octaves < 0 {
+		return nil, p.errorf(field+".octaves", "must be positive")
+	} else if t.Octaves > 0 {
+		octaves = t.Octaves
+	}
+	strength := func(def float64) float64 {
+		if t.Strength != nil {
+			return *t.Strength
+		}
+		return def
+	}
+	if t.Strength != nil && *t.Strength < 0 {
+		return nil, p.errorf(field+".strength", "must be non-negative")
+	}
+	noise := render.NewPerlin(t.Seed)
+
+	switch t.Type {
+	case "checker":
+		return &render.Checker{A: a, B: b, Size: size}, nil
+	case "stripes":
+		axis := []float64{1, 0, 0}
+		if t.Axis != nil {
+			axis = t.Axis
+		}
+		v, err := p.vector(field+".axis", axis)
+		if err != nil {
+			return nil, err
+		}
+		if v.Length() == 0 {
+			return nil, p.errorf(field+".axis", "must not be zero")
+		}
+		return &render.Stripes{A: a, B: b, Axis: v, Width: size}, nil
+	case "gradient":
+		start, err := p.vector(field+".start", t.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := p.vector(field+".end", t.End)
+		if err != nil {
+			return nil, err
+		}
+		return &render.Gradient{A: a, B: b, Start: start, End: end}, nil
+	case "noise":
+		return &render.NoiseTexture{A: a, B: b, Noise: noise, Scale: scale}, nil
+	case "turbulence":
+		return &render.Turbulence{A: a, B: b, Noise: noise, Scale: scale, Octaves: octaves}, nil
+	case "marble":
+		return &render.Marble{A: a, B: b, Noise: noise, Scale: scale, Octaves: octaves, Strength: strength(5)}, nil
+	case "wood":
+		center := []float64{0, 0, 0}
+		if t.Center != nil {
+			center = t.Center
+		}
+		c, err := p.vector(field+".center", center)
+		if err != nil {
+			return nil, err
+		}
+		return &render.Wood{A: a, B: b, Noise: noise, Center: c, Scale: scale, Octaves: octaves, Strength: strength(0.5)}, nil
+	}
+	return nil, p.errorf(field+".type", "unknown texture type %q", t.Type)
+}
+
+func (p *parser) imageTexture(field string, t *textureSection) (render.Texture, error) {
+	if t.File == "" {
+		return nil, p.errorf(field+".file", "missing value")
+	}
+	texture := &render.ImageTexture{}
+	if t.Wrap != "" {
+		var err error
+		if texture.Wrap, err = render.ParseWrapMode(t.Wrap); err != nil {
+			return nil, p.errorf(field+".wrap", "%s", err)
+		}
+	}
+	switch t.Filter {
+	case "", "bilinear":
+	case "nearest":
+		texture.Nearest = true
+	default:
+		return nil, p.errorf(field+".filter", "unknown filter %q", t.Filter)
+	}
+
+	path := p.resolve(t.File)
+	if img, ok := p.images[path]; ok {
+		texture.Image = img
+		return texture, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, p.errorf(field+".file", "%s", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, p.errorf(field+".file", "%s: %s", t.File, err)
+	}
+	texture.Image = render.NewImageTexture(img).Image
+	if p.images == nil {
+		p.images = make(map[string]*render.HDRImage)
+	}
+	p.images[path] = texture.Image
+	return texture, nil
+}
+
+func (p *parser) surface(field string, s *surfaceSection) (render.Material, error) {
+	if s.Material == "" {
+		return p.material(field, &s.materialSection)
+	}
+	if s.materialSection.isSet() {
+		return nil, p.errorf(field+".material", "shared material %q cannot be combined with inline material properties", s.Material)
+	}
+	material, ok := p.materials[s.Material]
+	if !ok {
+		return nil, p.errorf(field+".material", "unknown material %q", s.Material)
+	}
+	return material, nil
+}
+
+func (p *parser) material(field string, m *materialSection) (render.Material, error) {
+	if m.Type != "" && m.Type != "phong" {
+		return nil, p.errorf(field+".type", "unknown material type %q", m.Type)
+	}
+	material := &render.Phong{
+		Specular:        -1,
+		Reflective:      m.Reflective,
+		Transparency:    m.Transparency,
+		RefractiveIndex: 1.5,
+	}
+	var err error
+	if m.Texture != nil {
+		if material.Texture, err = p.texture(field+".texture", m.Texture); err != nil {
+			return nil, err
+		}
+	}
+	if m.SpecularTexture != nil {
+		if material.SpecularTexture, err = p.texture(field+".specular_texture", m.SpecularTexture); err != nil {
+			return nil, err
+		}
+	}
+	if m.ReflectiveTexture != nil {
+		if material.ReflectiveTexture, err = p.texture(field+".reflective_texture", m.ReflectiveTexture); err != nil {
+			return nil, err
+		}
+	}
+	if m.Texture != nil && m.Color == nil {
+		material.Color = render.White
+	} else if material.Color, err = p.color(field+".color", m.Color); err != nil {
+		return nil, err
+	}
+	if m.Specular != nil {
+		if *m.Specular < 0 && *m.Specular != -1 {
+			return nil, p.errorf(field+".specular", "must be non-negative, or -1 to disable highlights")
+		}
+		material.Specular = *m.Specular
+	}
+	if m.Reflective < 0 || m.Reflective > 1 {
+		return nil, p.errorf(field+".reflective", "must be between 0 and 1")
+	}
+	if m.Transparency < 0 || m.Transparency > 1 {
+		return nil, p.errorf(field+".transparency", "must be between 0 and 1")
+	}
+	if m.IOR != nil {
+		if *m.IOR <= 0 {
+			return nil, p.errorf(field+".ior", "must be positive")
+		}
+		material.RefractiveIndex = *m.IOR
+	}
+	return material, nil
+}
+
+func (p *parser) options(r *renderSection) (render.Options, error) {
+	if r.Width <= 0 {
+		return render.Options{}, p.errorf("render.width", "must be positive")
+	}
+	if r.Height <= 0 {
+		return render.Options{}, p.errorf("render.height", "must be positive")
+	}
+	if r.RecursionDepth < 0 || r.RecursionDepth > math.MaxInt8 {
+		return render.Options{}, p.errorf("render.recursion_depth", "must be between 0 and %d", math.MaxInt8)
+	}
+	if r.White <= 0 {
+		return render.Options{}, p.errorf("render.white", "must be positive")
+	}
+	operator, err := render.ParseToneMapper(r.ToneMap, r.White)
+	if err != nil {
+		return render.Options{}, p.errorf("render.tone_map", "%s", err)
+	}
+	if r.Samples < 1 {
+		return render.Options{}, p.errorf("render.samples", "must be positive")
+	}
+	pattern, err := render.ParseSamplePattern(r.SamplePattern)
+	if err != nil {
+		return render.Options{}, p.errorf("render.sample_pattern", "%s", err)
+	}
+	integrator, err := render.ParseIntegrator(r.Integrator)
+	if err != nil {
+		return render.Options{}, p.errorf("render.integrator", "%s", err)
+	}
+	return render.Options{
+		Integrator:     integrator,
+		Samples:        r.Samples,
+		Pattern:        pattern,
+		Width:          r.Width,
+		Height:         r.Height,
+		RecursionDepth: r.RecursionDepth,
+		ToneMapping: render.ToneMapping{
+			Exposure: r.Exposure,
+			Operator: operator,
+			White:    r.White,
+			Linear:   r.Linear,
+		},
+	}, nil
+}
+
+func (p *parser) vector(field string, v []float64) (render.Vec3, error) {
+	if v == nil {
+		return render.Vec3{}, p.errorf(field, "missing value")
+	}
+	if len(v) != 3 {
+		return render.Vec3{}, p.errorf(field, "expected 3 components, got %d", len(v))
+	}
+	return render.Vec3{X: v[0], Y: v[1], Z: v[2]}, nil
+}
+
+func (p *parser) color(field string, c []float64) (render.RGB, error) {
+	if c == nil {
+		return render.RGB{}, p.errorf(field, "missing value")
+	}
+	if len(c) != 3 {
+		return render.RGB{}, p.errorf(field, "expected 3 channels, got %d", len(c))
+	}
+	for _, ch := range c {
+		if ch < 0 || ch > 255 || ch != math.Trunc(ch) {
+			return render.RGB{}, p.errorf(field, "channels must be integers between 0 and 255")
+		}
+	}
+	// Colours are written as 8-bit sRGB, like in any paint program.
+	return render.FromSRGB(render.Color{R: uint8(c[0]), G: uint8(c[1]), B: uint8(c[2]), A: 255}), nil
+}
+
+// indexPositions maps the path of every value in a JSON document, e.g.
+// "spheres[1].radius", to the byte offset where the value starts.
+func indexPositions(data []byte) map[string]int64 {
+	positions := make(map[string]int64)
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		start := skipSeparators(data, dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		positions[path] = start
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := key.(string)
+				if path != "" {
+					child = path + "." + child
+				}
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	// The document already decoded successfully, so errors are not expected
+	// here; a partial index only degrades error messages.
+	_ = walk("")
+	return positions
+}
+
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return 1 + bytes.Count(data[:offset], []byte{'\n'})
+}
@@ -0,0 +1,96 @@
+package scene
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		line  int
+		field string
+	}{
+		{
+			name:  "unknown top-level field",
+			data:  `{"version": 1, "bogus": 1}`,
+			line:  1,
+			field: "bogus",
+		},
+		{
+			name: "unknown nested field",
+			data: `{"version": 1,
+"camera": {"fov": 60,
+  "color": [1, 2, 3]}}`,
+			line:  3,
+			field: "camera.color",
+		},
+		{
+			name: "unknown field whose name is known elsewhere",
+			data: `{"version": 1,
+"spheres": [{"center": [0, 0, 1], "radius": 1, "color": [1, 2, 3]},
+  {"center": [0, 0, 1], "radius": 1,
+   "colour": [1, 2, 3]}]}`,
+			line:  4,
+			field: "spheres[1].colour",
+		},
+		{
+			name: "type error",
+			data: `{"version": 1,
+"spheres": [{"center": [0, 0, 1], "radius": "big", "color": [1, 2, 3]}]}`,
+			line:  2,
+			field: "spheres[0].radius",
+		},
+		{
+			name: "missing value",
+			data: `{"version": 1,
+"spheres": [
+  {"center": [0, 0, 1], "radius": 1}]}`,
+			line:  3,
+			field: "spheres[0].color",
+		},
+		{
+			name: "invalid value",
+			data: `{"version": 1,
+"spheres": [{"center": [0, 0, 1],
+  "radius": -1, "color": [1, 2, 3]}]}`,
+			line:  3,
+			field: "spheres[0].radius",
+		},
+		{
+			name: "syntax error",
+			data: `{"version": 1,
+"spheres": [}`,
+			line: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse("test.json", []byte(tt.data))
+			var sceneErr *Error
+			if !errors.As(err, &sceneErr) {
+				t.Fatalf("Parse() error = %v, want *Error", err)
+			}
+			if sceneErr.Line != tt.line || sceneErr.Field != tt.field {
+				t.Errorf("Parse() error at line %d field %q, want line %d field %q (%v)",
+					sceneErr.Line, sceneErr.Field, tt.line, tt.field, err)
+			}
+		})
+	}
+}
+
+func TestBracketPath(t *testing.T) {
+	tests := map[string]string{
+		"version":               "version",
+		"spheres.0.radius":      "spheres[0].radius",
+		"triangles.1.uvs.2":     "triangles[1].uvs[2]",
+		"materials.red.color":   "materials.red.color",
+		"meshes.10.translate.0": "meshes[10].translate[0]",
+	}
+	for in, want := range tests {
+		if got := bracketPath(in); got != want {
+			t.Errorf("bracketPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}